@@ -0,0 +1,105 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"my_blockchain/internal/blockchain"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// ============================
+// 🚀 Account Routes
+// ============================
+
+// GetAccount returns the QRY balance, bonded stake and nonce of an address on the canonical chain
+func GetAccount(bc *blockchain.Blockchain) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		address := mux.Vars(r)["address"]
+		if err := blockchain.ValidateAddress(address); err != nil {
+			http.Error(w, "Invalid address: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		account := bc.State().Account(address)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"address":    account.Address,
+			"balance":    account.Balance,
+			"bonded":     account.Bonded,
+			"nonce":      account.Nonce,
+			"next_nonce": bc.NextNonce(address),
+		})
+	}
+}
+
+// SubmitTransfer queues a QRY transfer, paying an optional fee. Clients either sign
+// Transaction.SignBytes themselves and send from, key_type, public_key, nonce and signature, or
+// name an unlocked keystore wallet with wallet_id (the nonce is then the account's next one).
+func SubmitTransfer(bc *blockchain.Blockchain, ks *blockchain.Keystore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var request struct {
+			WalletID  string             `json:"wallet_id"`
+			From      string             `json:"from"`
+			To        string             `json:"to"`
+			Amount    int64              `json:"amount"`
+			Fee       int64              `json:"fee"`
+			Nonce     uint64             `json:"nonce"`
+			KeyType   blockchain.KeyType `json:"key_type"`
+			PublicKey string             `json:"public_key"`
+			Signature string             `json:"signature"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request format", http.StatusBadRequest)
+			return
+		}
+
+		var tx blockchain.Transaction
+		if request.PublicKey != "" {
+			// ✅ Client-signed transfer, verified by the admission rules
+			if request.KeyType == "" {
+				request.KeyType = blockchain.KeyTypeP256
+			}
+			transfer := blockchain.Transfer{
+				Sender: blockchain.Sender{
+					From:      request.From,
+					Nonce:     request.Nonce,
+					KeyType:   request.KeyType,
+					PublicKey: request.PublicKey,
+					Signature: request.Signature,
+				},
+				To:     request.To,
+				Amount: request.Amount,
+			}
+			tx = blockchain.NewSignedTransferTransaction(transfer, request.Fee)
+		} else {
+			// ✅ Custodial transfer: sign with the sender's unlocked keystore wallet
+			wallet, err := ks.Wallet(request.WalletID)
+			if err != nil {
+				writeWalletError(w, err)
+				return
+			}
+			tx, err = blockchain.NewTransferTransaction(wallet, request.To, request.Amount, request.Fee, bc.NextNonce(wallet.Address()))
+			if err != nil {
+				http.Error(w, "Failed to sign transfer", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		tx, err := bc.AddTransaction(tx)
+		var rejection *blockchain.Rejection
+		if errors.As(err, &rejection) {
+			writeRejection(w, rejection, tx)
+			return
+		}
+
+		fmt.Printf("💸 Transfer of %d QRY from %s to %s queued: %s\n", tx.Transfer.Amount, tx.Transfer.From, tx.Transfer.To, tx.TxID)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(tx)
+	}
+}
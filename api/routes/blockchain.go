@@ -2,10 +2,10 @@ package routes
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"my_blockchain/internal/blockchain"
 	"fmt"
-	"math/rand"
 )
 
 // ============================
@@ -16,11 +16,11 @@ import (
 func GetBlocks(bc *blockchain.Blockchain) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(bc.Chain)
+		json.NewEncoder(w).Encode(bc.ChainSnapshot())
 	}
 }
 
-// MineBlock mines a new block, ensuring validator approval using PoD
+// MineBlock mines a new block proposed by the scheduled validator and finalized by the consensus engine
 func MineBlock(bc *blockchain.Blockchain) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -29,30 +29,17 @@ func MineBlock(bc *blockchain.Blockchain) http.HandlerFunc {
 		mempoolTransactions := bc.Mempool.GetTransactions()
 		fmt.Printf("🔍 Mempool Before Mining: %v\n", mempoolTransactions)
 
-		if len(mempoolTransactions) == 0 {
+		// ✅ Mine transactions from the mempool into a block
+		newBlock, err := bc.MineBlock()
+		switch {
+		case errors.Is(err, blockchain.ErrNoTransactions):
 			http.Error(w, "❌ No transactions to mine!", http.StatusBadRequest)
 			return
-		}
-
-		// ✅ Select a validator randomly to validate this block
-		if len(bc.Consensus.Validators) == 0 {
-			http.Error(w, "❌ No registered validators! Cannot mine block.", http.StatusInternalServerError)
-			return
-		}
-		selectedValidator := bc.Consensus.Validators[rand.Intn(len(bc.Consensus.Validators))]
-		fmt.Printf("🔍 Selected Validator for Mining: %s\n", selectedValidator.ID)
-
-		// ✅ Mine transactions from the mempool into a block
-		newBlock := bc.MineBlock(blockchain.NewWallet())
-
-		if newBlock == nil {
-			http.Error(w, "❌ Block mining failed! Not added to blockchain.", http.StatusInternalServerError)
+		case errors.Is(err, blockchain.ErrNotProposer):
+			http.Error(w, "❌ "+err.Error(), http.StatusConflict)
 			return
-		}
-
-		// ✅ Ensure validator approval before adding the block
-		if !selectedValidator.ApproveBlock(*newBlock) {
-			http.Error(w, "❌ Block rejected by validator!", http.StatusForbidden)
+		case err != nil:
+			http.Error(w, "❌ Block mining failed! "+err.Error(), http.StatusInternalServerError)
 			return
 		}
 
@@ -63,3 +50,45 @@ func MineBlock(bc *blockchain.Blockchain) http.HandlerFunc {
 		json.NewEncoder(w).Encode(newBlock)
 	}
 }
+
+// GetProposer returns the validator scheduled to propose the next block in the current voting round
+func GetProposer(bc *blockchain.Blockchain) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		head, state := bc.Head() // ✅ The state after the head, read together with it
+		round := 0
+		if bc.BFT != nil {
+			if status := bc.BFT.Status(); status.Height == head.Index+1 {
+				round = status.Round
+			}
+		}
+		proposer := bc.Consensus.SelectProposer(state, head.Hash, round)
+		if proposer == nil {
+			http.Error(w, "No active validators: every validator is jailed or none is registered", http.StatusNotFound)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"height":  head.Index + 1,
+			"round":   round,
+			"ID":      proposer.ID,
+			"Address": proposer.Address,
+			"Stake":   state.VotingPower(proposer.ID),
+			"local":   proposer.Signer != nil, // ✅ Whether this node can propose the block
+		})
+	}
+}
+
+// GetConsensus returns the voting round in progress (PoD only)
+func GetConsensus(bc *blockchain.Blockchain) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if bc.BFT == nil {
+			http.Error(w, "Consensus engine "+bc.Consensus.Name()+" does not vote", http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(bc.BFT.Status())
+	}
+}
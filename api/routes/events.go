@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"encoding/json"
+	"my_blockchain/internal/blockchain"
+	"net/http"
+	"strconv"
+)
+
+// ============================
+// 🚀 Event Routes
+// ============================
+
+// GetReorgs returns recent chain reorganizations, optionally only those after ?since=<seq>
+func GetReorgs(bc *blockchain.Blockchain) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		since := 0
+		if value := r.URL.Query().Get("since"); value != "" {
+			seq, err := strconv.Atoi(value)
+			if err != nil {
+				http.Error(w, "Invalid since parameter", http.StatusBadRequest)
+				return
+			}
+			since = seq
+		}
+
+		json.NewEncoder(w).Encode(bc.Reorgs.Since(since))
+	}
+}
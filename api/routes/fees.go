@@ -0,0 +1,60 @@
+package routes
+
+import (
+	"encoding/json"
+	"my_blockchain/internal/blockchain"
+	"net/http"
+	"strconv"
+)
+
+// ============================
+// 🚀 Fee Routes
+// ============================
+
+// GetFeeEstimate suggests fees per byte from the transactions of recent blocks. The optional
+// blocks parameter sets how many blocks to look at; with size (bytes of a transaction, see
+// Transaction.Size) the suggested fees for that transaction are returned too.
+func GetFeeEstimate(bc *blockchain.Blockchain) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		blocks := blockchain.DefaultFeeEstimateBlocks
+		if value := r.URL.Query().Get("blocks"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				http.Error(w, "Invalid blocks", http.StatusBadRequest)
+				return
+			}
+			blocks = n
+		}
+
+		estimate := bc.EstimateFees(blocks)
+		response := map[string]interface{}{
+			"blocks":           estimate.Blocks,
+			"transactions":     estimate.Transactions,
+			"min_fee_per_byte": estimate.MinFeePerByte,
+			"fee_per_byte": map[string]float64{
+				"low":    estimate.Low,
+				"medium": estimate.Medium,
+				"high":   estimate.High,
+			},
+		}
+
+		// ✅ Fees for a transaction of the given size, rounded up to whole QRY
+		if value := r.URL.Query().Get("size"); value != "" {
+			size, err := strconv.Atoi(value)
+			if err != nil || size < 1 {
+				http.Error(w, "Invalid size", http.StatusBadRequest)
+				return
+			}
+			response["size"] = size
+			response["fee"] = map[string]int64{
+				"low":    blockchain.FeeFor(size, estimate.Low),
+				"medium": blockchain.FeeFor(size, estimate.Medium),
+				"high":   blockchain.FeeFor(size, estimate.High),
+			}
+		}
+
+		json.NewEncoder(w).Encode(response)
+	}
+}
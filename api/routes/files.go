@@ -0,0 +1,50 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"my_blockchain/internal/blockchain"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// ============================
+// 🚀 File Routes
+// ============================
+
+// GetFileProof returns an inclusion proof that a notarized file was sealed in a block
+func GetFileProof(bc *blockchain.Blockchain) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		fileHash := mux.Vars(r)["hash"]
+		proof, err := bc.ProveFile(fileHash)
+		if errors.Is(err, blockchain.ErrFileNotNotarized) {
+			http.Error(w, "File not found in any block", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to build inclusion proof", http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(proof)
+	}
+}
+
+// GetFileRecord returns what the chain records about a notarized file: its uploader, the
+// block notarizing it, its metadata and whether it was revoked
+func GetFileRecord(bc *blockchain.Blockchain) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		record, err := bc.FileRecord(mux.Vars(r)["hash"])
+		if errors.Is(err, blockchain.ErrFileNotNotarized) {
+			http.Error(w, "File not found in any block", http.StatusNotFound)
+			return
+		}
+
+		json.NewEncoder(w).Encode(record)
+	}
+}
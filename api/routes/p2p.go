@@ -29,7 +29,10 @@ func ConnectPeer(bc *blockchain.Blockchain) http.HandlerFunc {
 			return
 		}
 
-		bc.Network.ConnectToPeer(request.PeerAddress)
+		if err := bc.Network.ConnectToPeer(request.PeerAddress); err != nil {
+			http.Error(w, "Failed to connect to peer: "+err.Error(), http.StatusBadGateway)
+			return
+		}
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprintf(w, "🔗 Connected to peer: %s\n", request.PeerAddress)
 	}
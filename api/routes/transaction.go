@@ -2,10 +2,12 @@ package routes
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
-	"os"
+	"slices"
+	"strconv"
+	"time"
 	"my_blockchain/internal/blockchain"
 )
 
@@ -28,56 +30,119 @@ func GetTransactions(bc *blockchain.Blockchain) http.HandlerFunc {
 	}
 }
 
-// UploadFile handles file uploads and creates a transaction
-func UploadFile(bc *blockchain.Blockchain) http.HandlerFunc {
+// UploadFile handles file uploads and creates a transaction signed by the uploader, paying an
+// optional fee. Clients either sign UploadPayload(file hash, nonce, fee) themselves and send key_type
+// (p256 by default or ed25519), public_key, signature and nonce, or name an unlocked keystore wallet with wallet_id.
+func UploadFile(bc *blockchain.Blockchain, ks *blockchain.Keystore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
-		file, header, err := r.FormFile("file")
-		if err != nil {
-			http.Error(w, "Invalid file upload", http.StatusBadRequest)
+		publicKeyHex := r.FormValue("public_key")
+		signature := r.FormValue("signature")
+		walletID := r.FormValue("wallet_id")
+
+		var wallet *blockchain.Wallet
+		var publicKey blockchain.Verifier
+		var err error
+		if publicKeyHex != "" {
+			// ✅ Client-signed upload: the uploader's own key, verified below
+			keyType := blockchain.KeyType(r.FormValue("key_type"))
+			if keyType == "" {
+				keyType = blockchain.KeyTypeP256
+			}
+			publicKey, err = blockchain.ParsePublicKey(keyType, publicKeyHex)
+			if err != nil || signature == "" {
+				http.Error(w, "Invalid key_type, public_key or missing signature", http.StatusBadRequest)
+				return
+			}
+		} else {
+			// ✅ Custodial upload: sign with the uploader's unlocked keystore wallet
+			wallet, err = ks.Wallet(walletID)
+			if err != nil {
+				writeWalletError(w, err)
+				return
+			}
+			publicKey = wallet.PublicKey()
+		}
+
+		nonce := uint64(time.Now().UnixNano())
+		if value := r.FormValue("nonce"); value != "" {
+			nonce, err = strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				http.Error(w, "Invalid nonce", http.StatusBadRequest)
+				return
+			}
+		} else if wallet == nil {
+			http.Error(w, "Signed uploads require a nonce", http.StatusBadRequest)
 			return
 		}
-		defer file.Close()
 
-		// Ensure uploads directory exists
-		uploadDir := "uploads/"
-		if _, err := os.Stat(uploadDir); os.IsNotExist(err) {
-			os.Mkdir(uploadDir, os.ModePerm)
+		var fee int64
+		if value := r.FormValue("fee"); value != "" {
+			fee, err = strconv.ParseInt(value, 10, 64)
+			if err != nil || fee < 0 {
+				http.Error(w, "Invalid fee", http.StatusBadRequest)
+				return
+			}
 		}
 
-		// Save file locally
-		filePath := uploadDir + header.Filename
-		outFile, err := os.Create(filePath)
+		file, header, err := r.FormFile("file")
 		if err != nil {
-			http.Error(w, "Failed to save file", http.StatusInternalServerError)
+			http.Error(w, "Invalid file upload", http.StatusBadRequest)
 			return
 		}
-		defer outFile.Close()
-		_, err = io.Copy(outFile, file)
+		defer file.Close()
+
+		// ✅ Store the content under its SHA-256; the filename is kept as metadata only
+		meta, err := bc.Files.Put(file, header.Filename)
 		if err != nil {
-			http.Error(w, "Failed to write file to disk", http.StatusInternalServerError)
+			http.Error(w, "Failed to store file", http.StatusInternalServerError)
 			return
 		}
+		fileHash := meta.Hash
+		payload := blockchain.UploadPayload(fileHash, nonce, fee)
+
+		if wallet != nil {
+			signature, err = wallet.SignData(payload)
+			if err != nil {
+				http.Error(w, "Failed to sign transaction", http.StatusInternalServerError)
+				return
+			}
+		}
 
-		// Generate SHA-256 hash
-		fileHash, err := blockchain.HashFile(filePath)
+		// ✅ Proof-of-Data: score the content against the chain before it can be notarized
+		report, err := bc.ScoreFile(meta, blockchain.AddressFromPublicKey(publicKey))
 		if err != nil {
-			http.Error(w, "Failed to generate file hash", http.StatusInternalServerError)
+			bc.Files.Release(fileHash, "")
+			http.Error(w, "Failed to score file", http.StatusInternalServerError)
 			return
 		}
 
 		// Create transaction
-		wallet := blockchain.NewWallet()
-		signature, err := wallet.SignData(fileHash)
-		if err != nil {
-			http.Error(w, "Failed to sign transaction", http.StatusInternalServerError)
-			return
+		tx := blockchain.NewTransaction(fileHash, publicKey, meta.Size, report.Score, nonce, fee, signature)
+		tx.File.TrustFactors = report.Factors
+
+		// ✅ Count the transaction as a reference to the stored content, unless the same upload
+		// already holds one; the mempool releases it if the transaction is evicted, expires or
+		// is replaced before it is mined
+		resubmitted := slices.Contains(meta.Refs, tx.TxID)
+		if !resubmitted {
+			if err := bc.Files.AddRef(fileHash, tx.TxID); err != nil {
+				fmt.Println("⚠ Failed to record file reference:", err)
+			}
 		}
-		tx := blockchain.NewTransaction(fileHash, fmt.Sprintf("%x", wallet.PublicKey), header.Size, 0.0, signature)
 
-		// ✅ Add transaction to the mempool instead of directly adding it to a block
-		bc.AddTransaction(tx)
+		// ✅ Admit the transaction to the mempool instead of directly adding it to a block
+		tx, err = bc.AddTransaction(tx)
+		var rejection *blockchain.Rejection
+		if errors.As(err, &rejection) {
+			// ✅ Drop the content unless another transaction uses it, and tell the caller which rule failed
+			if !resubmitted {
+				bc.Files.Release(fileHash, tx.TxID)
+			}
+			writeRejection(w, rejection, tx)
+			return
+		}
 
 		fmt.Printf("✅ Transaction added to mempool: %+v\n", tx)
 
@@ -86,3 +151,69 @@ func UploadFile(bc *blockchain.Blockchain) http.HandlerFunc {
 	}
 }
 
+// SubmitTransaction queues a transaction of any type clients submit (see docs/transactions.md).
+// Clients either send a transaction they signed, or name an unlocked keystore wallet with
+// wallet_id to sign an account transaction as the wallet's next one.
+func SubmitTransaction(bc *blockchain.Blockchain, ks *blockchain.Keystore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var request struct {
+			WalletID    string                 `json:"wallet_id"`
+			Transaction blockchain.Transaction `json:"transaction"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request format", http.StatusBadRequest)
+			return
+		}
+
+		tx := request.Transaction
+		if request.WalletID != "" {
+			// ✅ Custodial transaction: sign with the sender's unlocked keystore wallet
+			wallet, err := ks.Wallet(request.WalletID)
+			if err != nil {
+				writeWalletError(w, err)
+				return
+			}
+			if err := blockchain.SignTransaction(wallet, &tx, bc.NextNonce(wallet.Address())); err != nil {
+				http.Error(w, "Failed to sign transaction: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		} else {
+			// ✅ Client-signed transaction, verified by the admission rules
+			tx = blockchain.NewSignedTransaction(tx)
+		}
+
+		tx, err := bc.AddTransaction(tx)
+		var rejection *blockchain.Rejection
+		if errors.As(err, &rejection) {
+			writeRejection(w, rejection, tx)
+			return
+		}
+
+		fmt.Printf("✅ %s transaction added to mempool: %s\n", tx.Type, tx.TxID)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(tx)
+	}
+}
+
+// writeRejection answers an upload that failed an admission rule with the rule, the reason
+// and the transaction (including its trust score factors)
+func writeRejection(w http.ResponseWriter, rejection *blockchain.Rejection, tx blockchain.Transaction) {
+	status := http.StatusUnprocessableEntity
+	switch rejection.Rule {
+	case blockchain.RuleSignature:
+		status = http.StatusUnauthorized
+	case blockchain.RuleDuplicate:
+		status = http.StatusConflict
+	case blockchain.RuleMempool:
+		status = http.StatusServiceUnavailable // ✅ Full: retry later or with a higher fee
+	}
+
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":       rejection.Error(),
+		"rejection":   rejection,
+		"transaction": tx,
+	})
+}
@@ -17,12 +17,22 @@ func GetValidators(bc *blockchain.Blockchain) http.HandlerFunc {
 		w.Header().Set("Content-Type", "application/json")
 
 		// ✅ Filter validators to only return public data
+		// ✅ Jailing and voting power come from the state of the chain
+		state := bc.State()
 		var publicValidators []map[string]interface{}
-		for _, v := range bc.Consensus.Validators {
+		for _, v := range bc.Consensus.Validators().List() {
+			record, _ := state.Validator(v.ID)
+			account := state.Account(v.Address)
 			publicValidators = append(publicValidators, map[string]interface{}{
-				"ID":        v.ID,
-				"PublicKey": v.PublicKey,
-				"Balance":   v.Balance,
+				"ID":          v.ID,
+				"Address":     v.Address,
+				"PublicKey":   v.PublicKey,
+				"Stake":       v.Stake,
+				"Bonded":      account.Bonded,
+				"VotingPower": state.VotingPower(v.ID),
+				"Balance":     account.Balance,
+				"Jailed":      record.Jailed,
+				"JailedAt":    record.JailedAt,
 			})
 		}
 
@@ -31,44 +41,36 @@ func GetValidators(bc *blockchain.Blockchain) http.HandlerFunc {
 	}
 }
 
-// RegisterValidator allows a new validator to join the network
-func RegisterValidator(bc *blockchain.Blockchain) http.HandlerFunc {
+// SubmitEvidence accepts two conflicting votes signed by one validator and queues the
+// double-signing evidence for the next block
+func SubmitEvidence(bc *blockchain.Blockchain) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
 		var request struct {
-			ID string `json:"ID"`
+			VoteA blockchain.Vote `json:"vote_a"`
+			VoteB blockchain.Vote `json:"vote_b"`
 		}
-
-		// ✅ Validate incoming request
 		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 			http.Error(w, "Invalid request format", http.StatusBadRequest)
 			return
 		}
 
-		// ✅ Prevent duplicate validator registration
-		for _, v := range bc.Consensus.Validators {
-			if v.ID == request.ID {
-				http.Error(w, "Validator already exists", http.StatusConflict)
-				return
-			}
+		// ✅ The votes must conflict and both carry the validator's signature
+		evidence, err := blockchain.NewDuplicateVoteEvidence(request.VoteA, request.VoteB)
+		if err == nil {
+			err = bc.SubmitEvidence(evidence)
+		}
+		if err != nil {
+			http.Error(w, "❌ "+err.Error(), http.StatusBadRequest)
+			return
 		}
 
-		// ✅ Create new validator with Public Key
-		newValidator := blockchain.NewValidator(request.ID)
-		bc.Consensus.RegisterValidator(newValidator)
-
-		fmt.Printf("✅ Validator Registered: %s | Public Key: %s\n", newValidator.ID, newValidator.PublicKey)
-
-		// ✅ Return structured response
-		w.WriteHeader(http.StatusCreated)
+		w.WriteHeader(http.StatusAccepted)
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"message":   "Validator registered successfully",
-			"validator": map[string]interface{}{
-				"ID":        newValidator.ID,
-				"PublicKey": newValidator.PublicKey,
-				"Balance":   newValidator.Balance,
-			},
+			"message":   "Evidence queued for the next block",
+			"validator": evidence.VoteA.ValidatorID,
+			"TxID":      blockchain.NewEvidenceTransaction(evidence).TxID,
 		})
 	}
 }
@@ -2,24 +2,172 @@ package routes
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"my_blockchain/internal/blockchain"
 )
 
-// CreateWallet generates a new wallet with cryptographic keys
-func CreateWallet() http.HandlerFunc {
+// ============================
+// 🚀 Wallet Routes
+// ============================
+
+// CreateWallet generates a new wallet and stores its key encrypted with the given password.
+// key_type selects the signature scheme (p256 by default, or ed25519).
+func CreateWallet(ks *blockchain.Keystore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		wallet := blockchain.NewWallet()
+		w.Header().Set("Content-Type", "application/json")
+
+		var request struct {
+			KeyType  blockchain.KeyType `json:"key_type"`
+			Password string             `json:"password"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Password == "" {
+			http.Error(w, "Invalid request: password required", http.StatusBadRequest)
+			return
+		}
+
+		// ✅ Only the wallet ID is returned; the private key never leaves the keystore
+		if request.KeyType == "" {
+			request.KeyType = blockchain.KeyTypeP256
+		}
+		info, err := ks.Create(request.KeyType, request.Password)
+		if errors.Is(err, blockchain.ErrUnsupportedKeyType) {
+			http.Error(w, "Invalid request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to create wallet", http.StatusInternalServerError)
+			return
+		}
+
 		w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(wallet)
+		json.NewEncoder(w).Encode(info)
+	}
+}
+
+// ListWallets returns the IDs and addresses of all stored wallets
+func ListWallets(ks *blockchain.Keystore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		infos, err := ks.List()
+		if err != nil {
+			http.Error(w, "Failed to list wallets", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(infos)
+	}
+}
+
+// ImportWallet stores an existing key, given as a hex private key or an exported key file
+func ImportWallet(ks *blockchain.Keystore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var request struct {
+			PrivateKey string          `json:"private_key"`
+			KeyFile    json.RawMessage `json:"key_file"`
+			Password   string          `json:"password"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Password == "" {
+			http.Error(w, "Invalid request: password required", http.StatusBadRequest)
+			return
+		}
+
+		var info blockchain.KeyInfo
+		var err error
+		switch {
+		case len(request.KeyFile) > 0:
+			info, err = ks.ImportKeyFile(request.KeyFile, request.Password)
+		case request.PrivateKey != "":
+			info, err = ks.Import(request.PrivateKey, request.Password)
+		default:
+			http.Error(w, "Invalid request: private_key or key_file required", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to import wallet: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(info)
+	}
+}
+
+// ExportWallet returns the encrypted key file of a wallet
+func ExportWallet(ks *blockchain.Keystore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var request struct {
+			WalletID string `json:"wallet_id"`
+			Password string `json:"password"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		keyFile, err := ks.Export(request.WalletID, request.Password)
+		if err != nil {
+			writeWalletError(w, err)
+			return
+		}
+		w.Write(keyFile)
+	}
+}
+
+// UnlockWallet decrypts a wallet so it can sign
+func UnlockWallet(ks *blockchain.Keystore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var request struct {
+			WalletID string `json:"wallet_id"`
+			Password string `json:"password"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		if err := ks.Unlock(request.WalletID, request.Password); err != nil {
+			writeWalletError(w, err)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"wallet_id": request.WalletID, "status": "unlocked"})
 	}
 }
 
-// SignData signs a given message using the wallet’s private key
-func SignData() http.HandlerFunc {
+// LockWallet forgets the decrypted key of a wallet
+func LockWallet(ks *blockchain.Keystore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
 		var request struct {
-			Message string `json:"message"`
+			WalletID string `json:"wallet_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		ks.Lock(request.WalletID)
+		json.NewEncoder(w).Encode(map[string]string{"wallet_id": request.WalletID, "status": "locked"})
+	}
+}
+
+// SignData signs a given message using an unlocked wallet from the keystore. The message is
+// signed with a prefix (see blockchain.MessageSignBytes), so the signature is no use as a
+// transaction or approval signature.
+func SignData(ks *blockchain.Keystore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var request struct {
+			WalletID string `json:"wallet_id"`
+			Message  string `json:"message"`
 		}
 
 		err := json.NewDecoder(r.Body).Decode(&request)
@@ -28,13 +176,67 @@ func SignData() http.HandlerFunc {
 			return
 		}
 
-		// Create a wallet and sign the message
-		wallet := blockchain.NewWallet()
-		signature, _ := wallet.SignData(request.Message)
+		wallet, err := ks.Wallet(request.WalletID)
+		if err != nil {
+			writeWalletError(w, err)
+			return
+		}
+		signature, err := wallet.SignMessage(request.Message)
+		if err != nil {
+			http.Error(w, "Failed to sign message", http.StatusInternalServerError)
+			return
+		}
 
 		// Send the signature as a response
-		response := map[string]string{"signature": signature}
+		response := map[string]string{
+			"wallet_id":  request.WalletID,
+			"address":    wallet.Address(),
+			"public_key": wallet.PublicKeyHex(),
+			"signature":  signature,
+		}
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(response)
 	}
 }
+
+// VerifyMessage checks a signature returned by SignData
+func VerifyMessage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var request struct {
+			KeyType   blockchain.KeyType `json:"key_type"`
+			PublicKey string             `json:"public_key"`
+			Message   string             `json:"message"`
+			Signature string             `json:"signature"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		publicKey, err := blockchain.ParsePublicKey(request.KeyType, request.PublicKey)
+		if err != nil {
+			http.Error(w, "Invalid public key", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"address": blockchain.AddressFromPublicKey(publicKey),
+			"valid":   blockchain.VerifyMessage(publicKey, request.Message, request.Signature),
+		})
+	}
+}
+
+// writeWalletError maps keystore errors to HTTP status codes
+func writeWalletError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, blockchain.ErrInvalidAddress):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, blockchain.ErrWalletNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, blockchain.ErrWalletLocked), errors.Is(err, blockchain.ErrWrongPassword):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -14,13 +14,15 @@ import (
 type APIServer struct {
 	Port       string
 	Blockchain *blockchain.Blockchain
+	Keystore   *blockchain.Keystore
 }
 
 // NewAPIServer initializes a new API server
-func NewAPIServer(port string, blockchain *blockchain.Blockchain) *APIServer {
+func NewAPIServer(port string, blockchain *blockchain.Blockchain, keystore *blockchain.Keystore) *APIServer {
 	return &APIServer{
 		Port:       port,
 		Blockchain: blockchain,
+		Keystore:   keystore,
 	}
 }
 
@@ -31,18 +33,39 @@ func (s *APIServer) Start() {
 	// Blockchain API Routes
 	router.HandleFunc("/blocks", routes.GetBlocks(s.Blockchain)).Methods("GET")
 	router.HandleFunc("/mine_block", routes.MineBlock(s.Blockchain)).Methods("POST")
+	router.HandleFunc("/proposer", routes.GetProposer(s.Blockchain)).Methods("GET")
+	router.HandleFunc("/consensus", routes.GetConsensus(s.Blockchain)).Methods("GET")
 
 	// Transaction Routes
 	router.HandleFunc("/transactions", routes.GetTransactions(s.Blockchain)).Methods("GET")
-	router.HandleFunc("/upload_file", routes.UploadFile(s.Blockchain)).Methods("POST")
+	router.HandleFunc("/transactions", routes.SubmitTransaction(s.Blockchain, s.Keystore)).Methods("POST")
+	router.HandleFunc("/upload_file", routes.UploadFile(s.Blockchain, s.Keystore)).Methods("POST")
+	router.HandleFunc("/fees/estimate", routes.GetFeeEstimate(s.Blockchain)).Methods("GET")
+
+	// Account Routes
+	router.HandleFunc("/accounts/{address}", routes.GetAccount(s.Blockchain)).Methods("GET")
+	router.HandleFunc("/transfer", routes.SubmitTransfer(s.Blockchain, s.Keystore)).Methods("POST")
+
+	// File Routes
+	router.HandleFunc("/files/{hash}", routes.GetFileRecord(s.Blockchain)).Methods("GET")
+	router.HandleFunc("/files/{hash}/proof", routes.GetFileProof(s.Blockchain)).Methods("GET")
+
+	// Event Routes
+	router.HandleFunc("/events/reorgs", routes.GetReorgs(s.Blockchain)).Methods("GET")
 
 	// Validator Routes
 	router.HandleFunc("/validators", routes.GetValidators(s.Blockchain)).Methods("GET")
-	router.HandleFunc("/register_validator", routes.RegisterValidator(s.Blockchain)).Methods("POST")
+	router.HandleFunc("/evidence", routes.SubmitEvidence(s.Blockchain)).Methods("POST")
 
 	// Wallet Routes
-	router.HandleFunc("/wallet/create", routes.CreateWallet()).Methods("POST")
-	router.HandleFunc("/wallet/sign", routes.SignData()).Methods("POST")
+	router.HandleFunc("/wallets", routes.ListWallets(s.Keystore)).Methods("GET")
+	router.HandleFunc("/wallet/create", routes.CreateWallet(s.Keystore)).Methods("POST")
+	router.HandleFunc("/wallet/import", routes.ImportWallet(s.Keystore)).Methods("POST")
+	router.HandleFunc("/wallet/export", routes.ExportWallet(s.Keystore)).Methods("POST")
+	router.HandleFunc("/wallet/unlock", routes.UnlockWallet(s.Keystore)).Methods("POST")
+	router.HandleFunc("/wallet/lock", routes.LockWallet(s.Keystore)).Methods("POST")
+	router.HandleFunc("/wallet/sign", routes.SignData(s.Keystore)).Methods("POST")
+	router.HandleFunc("/wallet/verify", routes.VerifyMessage()).Methods("POST")
 
 	// P2P Routes
 	router.HandleFunc("/start_peer", routes.StartPeer(s.Blockchain)).Methods("POST")
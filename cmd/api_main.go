@@ -3,22 +3,77 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 	"my_blockchain/api"
 	"my_blockchain/internal/blockchain"
 )
 
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Println("Usage: go run api_main.go <port>")
+		printUsage()
+		return
+	}
+
+	// ✅ Maintenance commands run against a data directory without starting the node
+	if command, ok := commands[os.Args[1]]; ok {
+		if err := command(os.Args[2:]); err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
 		return
 	}
 
 	port := os.Args[1]
 
-	// ✅ Initialize blockchain with the given port
-	blockchain := blockchain.NewBlockchain(port)
+	// ✅ Each node keeps its chain in its own data directory
+	dataDir := defaultDataDir(port)
+	if len(os.Args) > 2 {
+		dataDir = os.Args[2]
+	}
+
+	// ✅ Load the blockchain from disk and initialize it with the given port
+	bc, err := blockchain.NewBlockchain(port, dataDir)
+	if err != nil {
+		fmt.Println("❌ Failed to load blockchain:", err)
+		os.Exit(1)
+	}
+
+	// ✅ Operators set the lowest fee per byte the node admits with $POD_MIN_FEE_PER_BYTE
+	if value := os.Getenv("POD_MIN_FEE_PER_BYTE"); value != "" {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate < 0 {
+			fmt.Println("❌ Invalid POD_MIN_FEE_PER_BYTE:", value)
+			os.Exit(1)
+		}
+		bc.Admission.MinFeePerByte = rate
+	}
+	fmt.Printf("💸 Minimum fee: %.3f QRY per byte\n", bc.Admission.MinFeePerByte)
+
+	keystore, err := blockchain.OpenKeystore(dataDir)
+	if err != nil {
+		fmt.Println("❌ Failed to open keystore:", err)
+		os.Exit(1)
+	}
 
 	// ✅ Create and start the API server
-	apiServer := api.NewAPIServer(port, blockchain)
+	apiServer := api.NewAPIServer(port, bc, keystore)
 	apiServer.Start()
 }
+
+// defaultDataDir returns the data directory used by the node listening on port
+func defaultDataDir(port string) string {
+	return filepath.Join("data", "node-"+port)
+}
+
+func printUsage() {
+	fmt.Println("Usage: go run api_main.go <port> [data_dir]")
+	fmt.Println("       go run api_main.go init <data_dir> [genesis.json | pod | poa | dev]")
+	fmt.Println("       go run api_main.go migrate <data_dir> [genesis.json | pod | poa | dev]")
+	fmt.Println("       go run api_main.go validator-key <data_dir> <validator_id> [p256|ed25519]")
+	fmt.Println("       go run api_main.go verify <data_dir>")
+	fmt.Println("       go run api_main.go replay <data_dir>")
+	fmt.Println("       go run api_main.go fsck <data_dir>")
+	fmt.Println("       go run api_main.go wallet <create|list|import|export> <data_dir> [args]")
+	fmt.Println("       go run api_main.go verify-proof <proof.json> <file_hash>")
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"my_blockchain/internal/blockchain"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// commands maps CLI subcommands to their handlers
+var commands = map[string]func(args []string) error{
+	"fsck":          runFsck,
+	"init":          runInit,
+	"migrate":       runMigrate,
+	"replay":        runReplay,
+	"validator-key": runValidatorKey,
+	"verify":        runVerify,
+	"verify-proof":  runVerifyProof,
+	"wallet":        runWallet,
+}
+
+// runInit creates a data directory from a genesis file, or for a new development network
+// running the given consensus engine (PoD by default)
+func runInit(args []string) error {
+	if len(args) < 1 {
+		return errors.New("usage: init <data_dir> [genesis.json | pod | poa | dev]")
+	}
+
+	if len(args) < 2 || isConsensusEngine(args[1]) {
+		engine := blockchain.EnginePoD
+		if len(args) > 1 {
+			engine = args[1]
+		}
+		genesis, err := blockchain.InitDevDataDir(args[0], engine)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("🔑 Validator key of %s written to %s\n", genesis.Validators[0].ID, args[0])
+		return nil
+	}
+
+	genesis, err := blockchain.LoadGenesis(args[1])
+	if err != nil {
+		return err
+	}
+	return blockchain.InitDataDir(args[0], genesis)
+}
+
+// runMigrate archives the chain of a data directory created before genesis files and
+// initializes it like init
+func runMigrate(args []string) error {
+	if len(args) < 1 {
+		return errors.New("usage: migrate <data_dir> [genesis.json | pod | poa | dev]")
+	}
+	if _, err := blockchain.MigrateLegacyDataDir(args[0]); err != nil {
+		return err
+	}
+	return runInit(args)
+}
+
+// runValidatorKey creates a validator key in a data directory and prints the validator's
+// genesis entry, to be added to the genesis file of a multi-validator network
+func runValidatorKey(args []string) error {
+	if len(args) < 2 {
+		return errors.New("usage: validator-key <data_dir> <validator_id> [p256|ed25519]")
+	}
+	keyType := blockchain.KeyTypeP256
+	if len(args) > 2 {
+		keyType = blockchain.KeyType(args[2])
+	}
+
+	if _, _, err := blockchain.LoadValidatorKey(args[0]); !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%s already holds a validator key", args[0])
+	}
+	signer, err := blockchain.GenerateSigner(keyType)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(args[0], 0o755); err != nil {
+		return err
+	}
+	if err := blockchain.SaveValidatorKey(args[0], args[1], signer); err != nil {
+		return err
+	}
+
+	entry, err := json.MarshalIndent(blockchain.GenesisValidator{
+		ID:        args[1],
+		KeyType:   keyType,
+		PublicKey: blockchain.EncodePublicKey(signer.Public()),
+		Stake:     100,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("🔑 Validator key of %s written to %s. Genesis entry:\n%s\n", args[1], args[0], entry)
+	return nil
+}
+
+// isConsensusEngine reports whether name is a consensus engine rather than a genesis file
+func isConsensusEngine(name string) bool {
+	return name == blockchain.EnginePoD || name == blockchain.EnginePoA || name == blockchain.EngineDev
+}
+
+// runFsck re-hashes every stored file and reports integrity problems
+func runFsck(args []string) error {
+	if len(args) < 1 {
+		return errors.New("usage: fsck <data_dir>")
+	}
+
+	files, err := blockchain.OpenFileStore(args[0])
+	if err != nil {
+		return err
+	}
+
+	issues, err := files.Fsck()
+	if err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		fmt.Printf("❌ %s: %s\n", issue.Path, issue.Problem)
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("file store check found %d problem(s)", len(issues))
+	}
+
+	fmt.Println("✅ File store is consistent")
+	return nil
+}
+
+// runVerifyProof checks a saved inclusion proof offline
+func runVerifyProof(args []string) error {
+	if len(args) < 2 {
+		return errors.New("usage: verify-proof <proof.json> <file_hash>")
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	var proof blockchain.InclusionProof
+	if err := json.Unmarshal(data, &proof); err != nil {
+		return fmt.Errorf("decode proof: %w", err)
+	}
+
+	if err := blockchain.VerifyInclusionProof(proof, args[1]); err != nil {
+		return fmt.Errorf("proof rejected: %w", err)
+	}
+	fmt.Printf("✅ File %s was sealed in block #%d (%s) at %s\n", args[1], proof.Header.Index, proof.BlockHash, proof.Header.Timestamp)
+	return nil
+}
+
+// runVerify validates every stored block without starting the node
+func runVerify(args []string) error {
+	if len(args) < 1 {
+		return errors.New("usage: verify <data_dir>")
+	}
+
+	genesis, err := blockchain.LoadGenesis(filepath.Join(args[0], blockchain.GenesisFileName))
+	if err != nil {
+		return err
+	}
+
+	store, err := blockchain.OpenBlockStore(args[0])
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	chain, err := store.LoadChain()
+	if err != nil {
+		return err
+	}
+
+	engine, err := blockchain.NewConsensusEngine(genesis)
+	if err != nil {
+		return err
+	}
+
+	bc := &blockchain.Blockchain{
+		Config:    genesis.Config(),
+		Genesis:   genesis,
+		Consensus: engine,
+	}
+	if err := bc.ValidateChain(chain); err != nil {
+		return fmt.Errorf("chain is invalid: %w", err)
+	}
+
+	fmt.Printf("✅ Chain of %d block(s) is valid\n", len(chain))
+	return nil
+}
+
+// runReplay rebuilds the state from genesis by applying every stored block, and checks each
+// state root committed to by a block header against the replayed state
+func runReplay(args []string) error {
+	if len(args) < 1 {
+		return errors.New("usage: replay <data_dir>")
+	}
+
+	genesis, err := blockchain.LoadGenesis(filepath.Join(args[0], blockchain.GenesisFileName))
+	if err != nil {
+		return err
+	}
+
+	store, err := blockchain.OpenBlockStore(args[0])
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	chain, err := store.LoadChain()
+	if err != nil {
+		return err
+	}
+	if len(chain) == 0 {
+		return errors.New("chain is empty")
+	}
+
+	config := genesis.Config()
+	state := blockchain.NewState(genesis)
+	checked := 0
+	for i, block := range chain {
+		if i > 0 {
+			if state, err = blockchain.ApplyBlock(state, block); err != nil {
+				return fmt.Errorf("block #%d: %w", block.Index, err)
+			}
+		}
+
+		// ✅ A header committing to another root means this node computes a different state
+		if config.RulesAt(block.Index).StateRoot {
+			if root := state.Root(); block.StateRoot != root {
+				return fmt.Errorf("block #%d: header commits to state root %s, replay gives %s", block.Index, block.StateRoot, root)
+			}
+			checked++
+		}
+	}
+
+	fmt.Printf("✅ Replayed %d block(s), %d state root(s) match\n", len(chain), checked)
+	fmt.Printf("🌳 State root at block #%d: %s\n", state.Height(), state.Root())
+	return nil
+}
+
+// runWallet manages the encrypted keystore of a data directory
+func runWallet(args []string) error {
+	usage := errors.New("usage: wallet create <data_dir> [p256|ed25519] | wallet list <data_dir> | " +
+		"wallet import <data_dir> <private_key_hex|key_file.json> | wallet export <data_dir> <wallet_id>")
+	if len(args) < 2 {
+		return usage
+	}
+
+	ks, err := blockchain.OpenKeystore(args[1])
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "create":
+		keyType := blockchain.KeyTypeP256
+		if len(args) > 2 {
+			keyType = blockchain.KeyType(args[2])
+		}
+		info, err := ks.Create(keyType, readPassword())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Created %s wallet %s\n", info.KeyType, info.ID)
+
+	case "list":
+		infos, err := ks.List()
+		if err != nil {
+			return err
+		}
+		for _, info := range infos {
+			fmt.Printf("%s  %s  created %s\n", info.ID, info.KeyType, info.Created)
+		}
+
+	case "import":
+		if len(args) < 3 {
+			return usage
+		}
+		var info blockchain.KeyInfo
+		if data, err := os.ReadFile(args[2]); err == nil {
+			info, err = ks.ImportKeyFile(data, readPassword())
+			if err != nil {
+				return err
+			}
+		} else {
+			info, err = ks.Import(args[2], readPassword())
+			if err != nil {
+				return err
+			}
+		}
+		fmt.Printf("✅ Imported wallet %s\n", info.ID)
+
+	case "export":
+		if len(args) < 3 {
+			return usage
+		}
+		keyFile, err := ks.Export(args[2], readPassword())
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(keyFile))
+
+	default:
+		return usage
+	}
+	return nil
+}
+
+// readPassword takes the keystore password from $POD_PASSWORD or prompts for it on stdin
+func readPassword() string {
+	if password := os.Getenv("POD_PASSWORD"); password != "" {
+		return password
+	}
+
+	fmt.Fprint(os.Stderr, "🔑 Password: ")
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimRight(line, "\r\n")
+}
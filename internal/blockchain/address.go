@@ -0,0 +1,118 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Address format: base58(version || SHA-256(public key bytes)[:20] || checksum),
+// where checksum is the first 4 bytes of SHA-256(SHA-256(version || hash)).
+// The version byte tags the key type: P-256 addresses start with "Q", Ed25519 ones with "E".
+const (
+	addressHashLen     = 20
+	addressChecksumLen = 4
+)
+
+// addressVersions maps each key type to its address version byte
+var addressVersions = map[KeyType]byte{
+	KeyTypeP256:    0x3a,
+	KeyTypeEd25519: 0x21,
+}
+
+// ErrInvalidAddress is returned for malformed addresses, including mistyped ones
+var ErrInvalidAddress = errors.New("invalid address")
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// AddressFromPublicKey derives the checksummed, key-type tagged address of a public key
+func AddressFromPublicKey(publicKey Verifier) string {
+	hash := sha256.Sum256(publicKey.Bytes())
+
+	payload := append([]byte{addressVersions[publicKey.KeyType()]}, hash[:addressHashLen]...)
+	return base58Encode(append(payload, addressChecksum(payload)...))
+}
+
+// ParseAddress decodes an address and returns its key type and 20-byte public key hash
+func ParseAddress(address string) (KeyType, []byte, error) {
+	decoded, err := base58Decode(address)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrInvalidAddress, err)
+	}
+	if len(decoded) != 1+addressHashLen+addressChecksumLen {
+		return "", nil, fmt.Errorf("%w: wrong length", ErrInvalidAddress)
+	}
+
+	payload := decoded[:1+addressHashLen]
+	if !bytes.Equal(addressChecksum(payload), decoded[1+addressHashLen:]) {
+		return "", nil, fmt.Errorf("%w: checksum mismatch (typo?)", ErrInvalidAddress)
+	}
+	for keyType, version := range addressVersions {
+		if payload[0] == version {
+			return keyType, payload[1:], nil
+		}
+	}
+	return "", nil, fmt.Errorf("%w: unknown version 0x%02x", ErrInvalidAddress, payload[0])
+}
+
+// ValidateAddress reports whether an address is well formed with a valid checksum
+func ValidateAddress(address string) error {
+	_, _, err := ParseAddress(address)
+	return err
+}
+
+func addressChecksum(payload []byte) []byte {
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	return second[:addressChecksumLen]
+}
+
+// base58Encode encodes bytes with the Bitcoin base58 alphabet (leading zero bytes become '1')
+func base58Encode(input []byte) string {
+	n := new(big.Int).SetBytes(input)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+
+	var encoded []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		encoded = append(encoded, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range input {
+		if b != 0 {
+			break
+		}
+		encoded = append(encoded, base58Alphabet[0])
+	}
+
+	for i, j := 0, len(encoded)-1; i < j; i, j = i+1, j-1 {
+		encoded[i], encoded[j] = encoded[j], encoded[i]
+	}
+	return string(encoded)
+}
+
+// base58Decode decodes a base58 string, rejecting characters outside the alphabet
+func base58Decode(input string) ([]byte, error) {
+	if input == "" {
+		return nil, errors.New("empty")
+	}
+
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, c := range input {
+		digit := bytes.IndexRune([]byte(base58Alphabet), c)
+		if digit < 0 {
+			return nil, fmt.Errorf("invalid character %q", c)
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(digit)))
+	}
+
+	leadingZeros := 0
+	for leadingZeros < len(input) && input[leadingZeros] == base58Alphabet[0] {
+		leadingZeros++
+	}
+	return append(make([]byte, leadingZeros), n.Bytes()...), nil
+}
@@ -0,0 +1,82 @@
+package blockchain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testTypo replaces the character at i with another one of the base58 alphabet
+func testTypo(address string, i int) string {
+	replacement := base58Alphabet[(strings.IndexByte(base58Alphabet, address[i])+1)%len(base58Alphabet)]
+	return address[:i] + string(replacement) + address[i+1:]
+}
+
+func TestParseAddress(t *testing.T) {
+	for _, keyType := range []KeyType{KeyTypeP256, KeyTypeEd25519} {
+		wallet, err := NewWalletOfType(keyType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		address := wallet.Address()
+		swapAt := 5
+		for address[swapAt] == address[swapAt+1] {
+			swapAt++
+		}
+
+		tests := []struct {
+			name    string
+			address string
+			wantErr bool
+		}{
+			{name: "valid", address: address},
+			{name: "typo", address: testTypo(address, len(address)/2), wantErr: true},
+			{name: "typo in the checksum", address: testTypo(address, len(address)-1), wantErr: true},
+			{name: "swapped characters", address: address[:swapAt] + address[swapAt+1:swapAt+2] + address[swapAt:swapAt+1] + address[swapAt+2:], wantErr: true},
+			{name: "character outside the alphabet", address: "0" + address[1:], wantErr: true},
+			{name: "missing character", address: address[:len(address)-1], wantErr: true},
+			{name: "extra character", address: address + "1", wantErr: true},
+			{name: "empty", address: "", wantErr: true},
+		}
+
+		for _, tt := range tests {
+			t.Run(string(keyType)+"/"+tt.name, func(t *testing.T) {
+				gotType, hash, err := ParseAddress(tt.address)
+				if tt.wantErr {
+					if !errors.Is(err, ErrInvalidAddress) {
+						t.Fatalf("ParseAddress(%q) returned %v, expected ErrInvalidAddress", tt.address, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("ParseAddress(%q): %v", tt.address, err)
+				}
+				if gotType != keyType || len(hash) != addressHashLen {
+					t.Fatalf("parsed a %s address with a %d-byte hash, expected %s", gotType, len(hash), keyType)
+				}
+			})
+		}
+	}
+}
+
+func TestAddressPrefix(t *testing.T) {
+	tests := []struct {
+		keyType KeyType
+		prefix  string
+	}{
+		{keyType: KeyTypeP256, prefix: "Q"},
+		{keyType: KeyTypeEd25519, prefix: "E"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.keyType), func(t *testing.T) {
+			wallet, err := NewWalletOfType(tt.keyType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if address := wallet.Address(); !strings.HasPrefix(address, tt.prefix) {
+				t.Fatalf("%s address %s does not start with %s", tt.keyType, address, tt.prefix)
+			}
+		})
+	}
+}
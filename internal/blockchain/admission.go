@@ -0,0 +1,378 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// Default admission limits
+const (
+	DefaultMaxFileBytes  = 100 << 20 // Largest file a node admits (100 MiB)
+	DefaultMinFeePerByte = 0.01      // Lowest fee per byte a node admits once fees are allowed
+)
+
+// Names of the default admission rules, reported in rejections
+const (
+	RuleFormat     = "format"
+	RuleSignature  = "signature"
+	RuleSize       = "size"
+	RuleFee        = "fee"
+	RuleDuplicate  = "duplicate"
+	RuleAccount    = "account"
+	RuleRegistry   = "registry"
+	RuleTrustScore = "trust_score"
+	RuleApprovals  = "approvals"
+	RuleMempool    = "mempool"
+)
+
+// Rejection explains why a transaction was not admitted to the mempool
+type Rejection struct {
+	Rule   string `json:"rule"`   // Name of the admission rule that rejected the transaction
+	Reason string `json:"reason"` // What was wrong with the transaction
+}
+
+func (r *Rejection) Error() string {
+	return fmt.Sprintf("rejected by %s rule: %s", r.Rule, r.Reason)
+}
+
+// AdmissionRule is one check a transaction must pass to enter the mempool. Check
+// returns why the transaction is rejected, or nil. It may add to the transaction (e.g. approvals).
+type AdmissionRule struct {
+	Name         string
+	Check        func(bc *Blockchain, tx *Transaction) error
+	SkipRequeued bool // Not run for transactions a reorganization returns to the mempool
+}
+
+// Admission is the pipeline of rules that submitted transactions go through before they
+// enter the mempool. Rules run in order and the first failing one rejects the transaction.
+type Admission struct {
+	MaxFileBytes  int64   // Largest file admitted
+	MinFeePerByte float64 // Lowest fee per byte admitted once fees are allowed; 0 admits transactions without a fee
+	rules         []AdmissionRule
+	mu            sync.Mutex // Serializes admissions, so duplicates cannot slip in side by side
+}
+
+// NewAdmission returns the default pipeline: format, signature, size, fee, duplicate, account,
+// registry, trust score and validator approvals
+func NewAdmission() *Admission {
+	return &Admission{
+		MaxFileBytes:  DefaultMaxFileBytes,
+		MinFeePerByte: DefaultMinFeePerByte,
+		rules: []AdmissionRule{
+			{Name: RuleFormat, Check: checkFormat},
+			{Name: RuleSignature, Check: checkSignature},
+			{Name: RuleSize, Check: checkSize},
+			{Name: RuleFee, Check: checkFee},
+			{Name: RuleDuplicate, Check: checkDuplicate},
+			{Name: RuleAccount, Check: checkAccount},
+			{Name: RuleRegistry, Check: checkRegistry},
+			// ✅ Orphaned uploads were scored and approved before they were mined; scoring them
+			// again on the new chain could change the uploader's reputation factor
+			{Name: RuleTrustScore, Check: checkTrustScore, SkipRequeued: true},
+			{Name: RuleApprovals, Check: checkApprovals, SkipRequeued: true},
+		},
+	}
+}
+
+// Use appends a rule to the pipeline; it runs after the rules already added
+func (a *Admission) Use(rule AdmissionRule) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.rules = append(a.rules, rule)
+}
+
+// Rules returns the names of the rules in the order they run
+func (a *Admission) Rules() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	names := make([]string, len(a.rules))
+	for i, rule := range a.rules {
+		names[i] = rule.Name
+	}
+	return names
+}
+
+// AddTransaction admits a submitted transaction to the mempool (NOT directly to the blockchain).
+// It returns the admitted transaction, with the approvals of this node's validators, or a
+// *Rejection naming the rule it failed; the mempool rejects it as the last rule when it is full.
+func (bc *Blockchain) AddTransaction(tx Transaction) (Transaction, error) {
+	return bc.admit(tx, false)
+}
+
+// requeueTransaction admits a transaction of an orphaned block again, without the rules
+// that skip requeued transactions
+func (bc *Blockchain) requeueTransaction(tx Transaction) (Transaction, error) {
+	return bc.admit(tx, true)
+}
+
+func (bc *Blockchain) admit(tx Transaction, requeued bool) (Transaction, error) {
+	bc.Admission.mu.Lock()
+	defer bc.Admission.mu.Unlock()
+
+	for _, rule := range bc.Admission.rules {
+		if requeued && rule.SkipRequeued {
+			continue
+		}
+		if err := rule.Check(bc, &tx); err != nil {
+			fmt.Printf("❌ Transaction %s rejected by %s rule: %v\n", tx.TxID, rule.Name, err)
+			return tx, &Rejection{Rule: rule.Name, Reason: err.Error()}
+		}
+	}
+
+	if err := bc.Mempool.AddTransaction(tx); err != nil {
+		fmt.Printf("❌ Transaction %s rejected by %s rule: %v\n", tx.TxID, RuleMempool, err)
+		return tx, &Rejection{Rule: RuleMempool, Reason: err.Error()}
+	}
+	return tx, nil
+}
+
+// checkFormat checks that the transaction is a well-formed transaction of a type clients submit
+func checkFormat(bc *Blockchain, tx *Transaction) error {
+	switch tx.Type {
+	case TxEvidence:
+		return errors.New("evidence is submitted with SubmitEvidence, not as a transaction")
+	case TxCoinbase:
+		return errors.New("a coinbase is only created by the block proposer")
+	}
+	if err := tx.checkFormat(); err != nil {
+		return err
+	}
+	if !txTypes[tx.Type].submit {
+		return fmt.Errorf("%s transactions cannot be submitted", tx.Type)
+	}
+	return nil
+}
+
+// checkSignature checks the uploader's signature over the file hash and nonce, or the
+// sender's signature over an account transaction
+func checkSignature(bc *Blockchain, tx *Transaction) error {
+	return bc.verifySignatures(tx)
+}
+
+// checkSize checks a file against the node's limit and the transaction against the block size limit
+func checkSize(bc *Blockchain, tx *Transaction) error {
+	if tx.isFile() && tx.File.Size > bc.Admission.MaxFileBytes {
+		return fmt.Errorf("file takes %d bytes, limit is %d", tx.File.Size, bc.Admission.MaxFileBytes)
+	}
+	if size := tx.Size(); size > bc.Config.Params.MaxBlockBytes {
+		return fmt.Errorf("transaction takes %d bytes, more than a block may hold (%d)", size, bc.Config.Params.MaxBlockBytes)
+	}
+	return nil
+}
+
+// checkFee requires fees to be allowed by the rules of the next block, and then the node's
+// minimum fee per byte
+func checkFee(bc *Blockchain, tx *Transaction) error {
+	if rules := bc.Config.RulesAt(bc.State().Height() + 1); !rules.Fees {
+		if tx.Fee > 0 {
+			return fmt.Errorf("block version %d does not allow fees yet", rules.Version)
+		}
+		return nil // ✅ No transaction could pay the minimum
+	}
+	if rate := tx.FeePerByte(); rate < bc.Admission.MinFeePerByte {
+		return fmt.Errorf("fee of %.3f QRY per byte is below the minimum of %.3f (%d QRY for %d bytes)",
+			rate, bc.Admission.MinFeePerByte, FeeFor(tx.Size(), bc.Admission.MinFeePerByte), tx.Size())
+	}
+	return nil
+}
+
+// checkDuplicate rejects a transaction or file that is already pending or notarized. A
+// transaction taking the place of a pending one (same sender nonce, or same file) must
+// replace it: same payer and a fee bumped by the mempool's MinFeeBump.
+func checkDuplicate(bc *Blockchain, tx *Transaction) error {
+	if bc.Mempool.Has(tx.TxID) {
+		return fmt.Errorf("transaction %s is already pending", tx.TxID)
+	}
+	if pending, ok := bc.Mempool.Conflict(*tx); ok {
+		if err := bc.Mempool.CheckReplacement(pending, *tx); err != nil {
+			return err
+		}
+	}
+	if !tx.isFile() {
+		return nil
+	}
+
+	// ✅ A file can only be notarized once, even after it was revoked
+	if record, err := bc.FileRecord(tx.File.FileHash); err == nil {
+		return fmt.Errorf("file %s is already notarized in block #%d", tx.File.FileHash, record.Height)
+	}
+	return checkUploadNonce(bc, tx)
+}
+
+// checkUploadNonce rejects an upload whose nonce its uploader already used on chain or in
+// another pending upload, once the next block's rules make upload nonces single-use
+func checkUploadNonce(bc *Blockchain, tx *Transaction) error {
+	state := bc.State()
+	if !bc.Config.RulesAt(state.Height() + 1).UploadNonces {
+		return nil
+	}
+	file := tx.File
+	if state.UploadNonceUsed(file.Uploader, file.Nonce) {
+		return fmt.Errorf("uploader %s already used nonce %d", file.Uploader, file.Nonce)
+	}
+	replaced, _ := bc.Mempool.Conflict(*tx)
+	for _, pending := range bc.Mempool.GetTransactions() {
+		if pending.isFile() && pending.TxID != replaced.TxID &&
+			pending.File.Uploader == file.Uploader && pending.File.Nonce == file.Nonce {
+			return fmt.Errorf("nonce %d is used by pending upload %s of %s", file.Nonce, pending.TxID, file.Uploader)
+		}
+	}
+	return nil
+}
+
+// checkAccount requires an account transaction to use the sender's next nonce after its
+// pending transactions, and the payer to afford the transaction and its fee on top of them:
+// no overdrafts, no unstaking more than is bonded, and only validators stake. A replacement
+// reuses the nonce of the transaction it replaces, whose spending no longer counts.
+func checkAccount(bc *Blockchain, tx *Transaction) error {
+	payer := tx.Payer()
+	if payer == "" {
+		return nil
+	}
+	state := bc.State()
+	account := state.Account(payer)
+	replaced, replacing := bc.Mempool.Conflict(*tx)
+	pending := bc.pendingFor(payer, replaced.TxID)
+
+	if sender := tx.Sender(); sender != nil && !replacing {
+		if expected := account.Nonce + pending.count + 1; sender.Nonce != expected {
+			return fmt.Errorf("nonce %d, account %s expects %d", sender.Nonce, sender.From, expected)
+		}
+	}
+	spend := tx.Fee
+	switch tx.Type {
+	case TxTransfer:
+		spend += tx.Transfer.Amount
+	case TxStake:
+		spend += tx.Stake.Amount
+	case TxUnstake:
+		if available := account.Bonded - pending.unbonded; tx.Stake.Amount > available {
+			return fmt.Errorf("%w: %s has %d QRY bonded, unstake is %d", ErrInsufficientBond, payer, available, tx.Stake.Amount)
+		}
+	}
+	if available := account.Balance - pending.spent; spend > available {
+		return fmt.Errorf("%w: %s has %d QRY available, %s and fee take %d", ErrInsufficientBalance, payer, available, tx.Type, spend)
+	}
+	if tx.Type == TxUnstake {
+		return unstakingValidator(state, payer)
+	}
+	if tx.Stake != nil {
+		return stakingValidator(state, payer)
+	}
+	return nil
+}
+
+// checkRegistry requires a metadata update or revocation to target a file the sender
+// notarized and that is not revoked, nor being revoked by a pending transaction
+func checkRegistry(bc *Blockchain, tx *Transaction) error {
+	var fileHash string
+	switch tx.Type {
+	case TxMetadata:
+		fileHash = tx.Metadata.FileHash
+	case TxRevocation:
+		fileHash = tx.Revocation.FileHash
+	default:
+		return nil
+	}
+
+	record, err := bc.FileRecord(fileHash)
+	if err != nil {
+		return err
+	}
+	if record.Uploader != tx.Sender().From {
+		return fmt.Errorf("file %s was notarized by %s, not %s", fileHash, record.Uploader, tx.Sender().From)
+	}
+	if record.Revoked {
+		return fmt.Errorf("file %s was revoked in block #%d", fileHash, record.RevokedAt)
+	}
+	replaced, _ := bc.Mempool.Conflict(*tx)
+	for _, pending := range bc.Mempool.GetTransactions() {
+		if pending.Revocation != nil && pending.Revocation.FileHash == fileHash && pending.TxID != replaced.TxID {
+			return fmt.Errorf("file %s is being revoked by transaction %s", fileHash, pending.TxID)
+		}
+	}
+	return nil
+}
+
+// pendingAccount is what an address's pending transactions use up
+type pendingAccount struct {
+	count    uint64 // Pending transactions signed by the address
+	spent    int64  // QRY they take from its balance (fees, transfers and stakes)
+	unbonded int64  // QRY they release from its bonded stake
+}
+
+// pendingFor returns what the transactions an address pays for in the mempool use up,
+// leaving out the transaction with TxID except (one being replaced)
+func (bc *Blockchain) pendingFor(address string, except string) pendingAccount {
+	var pending pendingAccount
+	for _, tx := range bc.Mempool.GetTransactions() {
+		if tx.Payer() != address || (except != "" && tx.TxID == except) {
+			continue
+		}
+		if tx.Sender() != nil {
+			pending.count++
+		}
+		pending.spent += tx.Fee
+		switch tx.Type {
+		case TxTransfer:
+			pending.spent += tx.Transfer.Amount
+		case TxStake:
+			pending.spent += tx.Stake.Amount
+		case TxUnstake:
+			pending.unbonded += tx.Stake.Amount
+		}
+	}
+	return pending
+}
+
+// NextNonce returns the nonce of an address's next transaction, after its pending ones
+func (bc *Blockchain) NextNonce(address string) uint64 {
+	return bc.State().Account(address).Nonce + bc.pendingFor(address, "").count + 1
+}
+
+// checkTrustScore rescores the file from the content this node stores: the transaction must
+// carry the node's score, and it must reach the Proof-of-Data score validators approve blocks with
+func checkTrustScore(bc *Blockchain, tx *Transaction) error {
+	if !tx.isFile() {
+		return nil
+	}
+	report, err := bc.RescoreFile(tx.File)
+	if err != nil {
+		return err
+	}
+	tx.File.TrustFactors = report.Factors
+	if roundScore(tx.File.TrustScore) != roundScore(report.Score) {
+		return fmt.Errorf("trust score %.2f does not match the %.2f this node scores the file", tx.File.TrustScore, report.Score)
+	}
+	if report.Score < MinTrustScore {
+		return fmt.Errorf("trust score %.2f is below the minimum of %.0f", report.Score, MinTrustScore)
+	}
+	return nil
+}
+
+// roundScore rounds a trust score to the two decimals it is shown with, so scores computed
+// on different platforms compare equal
+func roundScore(score float64) float64 {
+	return math.Round(score*100) / 100
+}
+
+// checkApprovals has this node's active validators approve an upload; a node
+// without a validator key admits it without approvals
+func checkApprovals(bc *Blockchain, tx *Transaction) error {
+	if bc.Consensus == nil || !tx.isFile() {
+		return nil
+	}
+	for _, v := range bc.Consensus.Validators().Active(bc.State()) {
+		if v.Signer == nil {
+			continue
+		}
+		if err := v.ValidateTransaction(tx, bc); err != nil {
+			return fmt.Errorf("validator %s: %w", v.ID, err)
+		}
+	}
+	return nil
+}
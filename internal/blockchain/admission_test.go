@@ -0,0 +1,247 @@
+package blockchain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testContent is text that scores well above MinTrustScore for a new uploader
+var testContent = strings.Repeat("Proof-of-Data notarizes the content of every uploaded file. ", 20)
+
+// testSignedUpload stores content on the node and returns its upload by wallet, with the
+// trust score the node gives it plus scoreOffset
+func testSignedUpload(t *testing.T, bc *Blockchain, wallet *Wallet, content string, nonce uint64, scoreOffset float64) Transaction {
+	t.Helper()
+	meta, err := bc.Files.Put(strings.NewReader(content), "test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := bc.ScoreFile(meta, wallet.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	const fee = 10
+	signature, err := wallet.SignData(UploadPayload(meta.Hash, nonce, fee))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewTransaction(meta.Hash, wallet.PublicKey(), meta.Size, report.Score+scoreOffset, nonce, fee, signature)
+}
+
+func TestAdmissionRejections(t *testing.T) {
+	genesis, keys := testGenesisKeys(t, []int64{100})
+	genesis.Params.Consensus = EngineDev
+	alice, _ := NewWalletOfType(KeyTypeEd25519)
+	bob, _ := NewWalletOfType(KeyTypeEd25519)
+	genesis.Allocations = []GenesisAllocation{{Address: alice.Address(), Amount: 1000}}
+
+	tests := []struct {
+		name     string
+		pending  func(t *testing.T, bc *Blockchain) []Transaction // Admitted first
+		tx       func(t *testing.T, bc *Blockchain) Transaction
+		requeued bool   // Whether tx is requeued by a reorganization instead of submitted
+		wantRule string // Rule that rejects tx; empty when it is admitted
+	}{
+		{
+			name: "valid transfer",
+			tx: func(t *testing.T, bc *Blockchain) Transaction {
+				return testSignedTransfer(t, alice, bob.Address(), 100, 1)
+			},
+		},
+		{
+			name: "coinbase",
+			tx: func(t *testing.T, bc *Blockchain) Transaction {
+				return NewCoinbaseTransaction(1, []Payout{{Address: alice.Address(), Amount: 100}})
+			},
+			wantRule: RuleFormat,
+		},
+		{
+			name: "tampered amount",
+			tx: func(t *testing.T, bc *Blockchain) Transaction {
+				tx := testSignedTransfer(t, alice, bob.Address(), 100, 1)
+				tx.Transfer.Amount = 900
+				return tx
+			},
+			wantRule: RuleFormat,
+		},
+		{
+			name: "signed by another key",
+			tx: func(t *testing.T, bc *Blockchain) Transaction {
+				tx := testSignedTransfer(t, bob, bob.Address(), 100, 1)
+				tx.Transfer.From = alice.Address()
+				tx.TxID = tx.calculateTxID()
+				return tx
+			},
+			wantRule: RuleSignature,
+		},
+		{
+			name: "fee below the minimum",
+			tx: func(t *testing.T, bc *Blockchain) Transaction {
+				tx, err := NewTransferTransaction(alice, bob.Address(), 100, 1, 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return tx
+			},
+			wantRule: RuleFee,
+		},
+		{
+			name: "already pending",
+			pending: func(t *testing.T, bc *Blockchain) []Transaction {
+				return []Transaction{testSignedTransfer(t, alice, bob.Address(), 100, 1)}
+			},
+			tx:       func(t *testing.T, bc *Blockchain) Transaction { return bc.Mempool.GetTransactions()[0] },
+			wantRule: RuleDuplicate,
+		},
+		{
+			name: "replacement without a fee bump",
+			pending: func(t *testing.T, bc *Blockchain) []Transaction {
+				return []Transaction{testSignedTransfer(t, alice, bob.Address(), 100, 1)}
+			},
+			tx: func(t *testing.T, bc *Blockchain) Transaction {
+				return testSignedTransfer(t, alice, bob.Address(), 200, 1)
+			},
+			wantRule: RuleDuplicate,
+		},
+		{
+			name: "nonce gap",
+			tx: func(t *testing.T, bc *Blockchain) Transaction {
+				return testSignedTransfer(t, alice, bob.Address(), 100, 2)
+			},
+			wantRule: RuleAccount,
+		},
+		{
+			name: "overdraft with pending transfers",
+			pending: func(t *testing.T, bc *Blockchain) []Transaction {
+				return []Transaction{testSignedTransfer(t, alice, bob.Address(), 600, 1)}
+			},
+			tx: func(t *testing.T, bc *Blockchain) Transaction {
+				return testSignedTransfer(t, alice, bob.Address(), 400, 2)
+			},
+			wantRule: RuleAccount,
+		},
+		{
+			name: "stake by a non-validator",
+			tx: func(t *testing.T, bc *Blockchain) Transaction {
+				tx := NewStakeTransaction(100, false)
+				tx.Fee = 10
+				if err := SignTransaction(alice, &tx, 1); err != nil {
+					t.Fatal(err)
+				}
+				return tx
+			},
+			wantRule: RuleAccount,
+		},
+		{
+			name: "valid upload",
+			tx: func(t *testing.T, bc *Blockchain) Transaction {
+				return testSignedUpload(t, bc, alice, testContent, 1, 0)
+			},
+		},
+		{
+			name: "trust score above the node's",
+			tx: func(t *testing.T, bc *Blockchain) Transaction {
+				return testSignedUpload(t, bc, alice, testContent, 1, 5)
+			},
+			wantRule: RuleTrustScore,
+		},
+		{
+			name: "trust score differing after rounding",
+			tx: func(t *testing.T, bc *Blockchain) Transaction {
+				return testSignedUpload(t, bc, alice, testContent, 1, 0.01)
+			},
+			wantRule: RuleTrustScore,
+		},
+		{
+			name: "trust score equal once rounded",
+			tx: func(t *testing.T, bc *Blockchain) Transaction {
+				return testSignedUpload(t, bc, alice, testContent, 1, 0.001)
+			},
+		},
+		{
+			name: "requeued upload is not scored again",
+			tx: func(t *testing.T, bc *Blockchain) Transaction {
+				return testSignedUpload(t, bc, alice, testContent, 1, 5)
+			},
+			requeued: true,
+		},
+		{
+			name: "content not stored",
+			tx: func(t *testing.T, bc *Blockchain) Transaction {
+				tx := testSignedUpload(t, bc, alice, testContent, 1, 0)
+				if err := bc.Files.Release(tx.File.FileHash, ""); err != nil && !errors.Is(err, ErrFileNotFound) {
+					t.Fatal(err)
+				}
+				return tx
+			},
+			wantRule: RuleTrustScore,
+		},
+		{
+			name: "near copy of a pending upload",
+			pending: func(t *testing.T, bc *Blockchain) []Transaction {
+				return []Transaction{testSignedUpload(t, bc, alice, testContent, 1, 0)}
+			},
+			tx: func(t *testing.T, bc *Blockchain) Transaction {
+				return testSignedUpload(t, bc, alice, "p"+testContent[1:], 2, 0)
+			},
+			wantRule: RuleTrustScore,
+		},
+		{
+			name: "upload nonce used by a pending upload",
+			pending: func(t *testing.T, bc *Blockchain) []Transaction {
+				return []Transaction{testSignedUpload(t, bc, alice, testContent, 1, 0)}
+			},
+			tx: func(t *testing.T, bc *Blockchain) Transaction {
+				return testSignedUpload(t, bc, alice, strings.Repeat("Another file with its own content. ", 20), 1, 0)
+			},
+			wantRule: RuleDuplicate,
+		},
+		{
+			name: "payer limit",
+			pending: func(t *testing.T, bc *Blockchain) []Transaction {
+				bc.Mempool.MaxPerUploader = 1
+				return []Transaction{testSignedTransfer(t, alice, bob.Address(), 100, 1)}
+			},
+			tx: func(t *testing.T, bc *Blockchain) Transaction {
+				return testSignedTransfer(t, alice, bob.Address(), 100, 2)
+			},
+			wantRule: RuleMempool,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bc := testBlockchain(t, genesis, keys[0])
+			if tt.pending != nil {
+				for _, tx := range tt.pending(t, bc) {
+					if _, err := bc.AddTransaction(tx); err != nil {
+						t.Fatalf("admit pending transaction: %v", err)
+					}
+				}
+			}
+
+			admit := bc.AddTransaction
+			if tt.requeued {
+				admit = bc.requeueTransaction
+			}
+			admitted, err := admit(tt.tx(t, bc))
+			if tt.wantRule == "" {
+				if err != nil {
+					t.Fatalf("rejected: %v", err)
+				}
+				if !bc.Mempool.Has(admitted.TxID) {
+					t.Fatal("admitted transaction is not pending")
+				}
+				if admitted.isFile() && !tt.requeued && !admitted.approvedBy("v0") {
+					t.Fatal("admitted upload has no approval from the node's validator")
+				}
+				return
+			}
+			var rejection *Rejection
+			if !errors.As(err, &rejection) || rejection.Rule != tt.wantRule {
+				t.Fatalf("admission returned %v, expected a rejection by the %s rule", err, tt.wantRule)
+			}
+		})
+	}
+}
@@ -0,0 +1,652 @@
+package blockchain
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ============================
+// Votes and commit certificates
+// ============================
+
+// VoteType is the step of a voting round a vote is cast in
+type VoteType uint8
+
+const (
+	VotePrevote   VoteType = 1 // First step: the validator saw a valid proposal (or votes nil)
+	VotePrecommit VoteType = 2 // Second step: the validator saw more than 2/3 prevote the block
+)
+
+func (t VoteType) String() string {
+	switch t {
+	case VotePrevote:
+		return "prevote"
+	case VotePrecommit:
+		return "precommit"
+	}
+	return fmt.Sprintf("vote type %d", uint8(t))
+}
+
+// Vote is a validator's signed prevote or precommit for a block in one round of a height.
+// An empty BlockHash is a vote for no block (nil).
+type Vote struct {
+	Type        VoteType
+	Height      int    // Index of the block being decided
+	Round       int    // Voting round within the height, from 0
+	BlockHash   string // Hash of the proposed block, or "" for nil
+	ValidatorID string // ID of the voting validator
+	Signature   string // Signature over SignBytes with the validator's key
+}
+
+// CommitCertificate proves a block is final: precommits for its hash, cast in one round
+// by validators holding more than two-thirds of the voting power
+type CommitCertificate struct {
+	Round      int
+	Precommits []Vote
+}
+
+// HasPrecommit reports whether the validator with the given ID precommitted the block
+func (c *CommitCertificate) HasPrecommit(validatorID string) bool {
+	for _, vote := range c.Precommits {
+		if vote.ValidatorID == validatorID {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================
+// Voting rounds
+// ============================
+
+// maxRounds bounds the voting rounds a height runs after the latest proposal or mining
+// call, and how far ahead of the current round proposals and votes are accepted
+const maxRounds = 5
+
+// BFTTimeouts are the voting round timeouts
+type BFTTimeouts struct {
+	Round      time.Duration // How long round 0 waits for a commit before the next round starts
+	RoundDelta time.Duration // Added to Round for every later round
+	Prevote    time.Duration // How long a validator waits for 2/3 prevotes before precommitting nil
+}
+
+// DefaultBFTTimeouts returns the timeouts used by nodes
+func DefaultBFTTimeouts() BFTTimeouts {
+	return BFTTimeouts{
+		Round:      3 * time.Second,
+		RoundDelta: time.Second,
+		Prevote:    time.Second,
+	}
+}
+
+// forRound returns how long a round waits for a commit
+func (t BFTTimeouts) forRound(round int) time.Duration {
+	return t.Round + time.Duration(round)*t.RoundDelta
+}
+
+// until returns how long the rounds from first to last take when none commits
+func (t BFTTimeouts) until(first, last int) time.Duration {
+	var total time.Duration
+	for round := first; round <= last; round++ {
+		total += t.forRound(round)
+	}
+	return total
+}
+
+// voteKey identifies one step of one round
+type voteKey struct {
+	Round int
+	Type  VoteType
+}
+
+// BFT runs the networked voting that makes PoD blocks final. The proposer scheduled for the
+// round broadcasts its block; every validator node prevotes for it with its own key if the
+// block is valid, precommits once more than 2/3 of the voting power prevoted it, and the
+// block is final once more than 2/3 precommitted. Votes are gossiped to all peers. A round
+// without a commit times out on every node that saw it, and the validator scheduled for the
+// next round proposes.
+type BFT struct {
+	bc       *Blockchain
+	pod      *PoDConsensus
+	Timeouts BFTTimeouts
+
+	mu          sync.Mutex
+	height      int                         // Height being decided (head index + 1)
+	prevHash    string                      // Hash of the head
+	state       *State                      // State after the head: validators, jailing and voting power of the height
+	round       int                         // Highest round entered at this height
+	lastRound   int                         // Round after which timeouts stop starting new rounds
+	stalled     bool                        // The last round timed out; mining again starts the next one
+	timed       map[int]bool                // Rounds whose timeout is running or expired
+	proposals   map[int]Block               // Proposal of each round
+	checked     map[string]error            // Validation result of each proposed block hash
+	votes       map[voteKey]map[string]Vote // Votes of the height by step, then validator ID
+	voted       map[voteKey]bool            // Steps this node has voted in
+	lockedHash  string                      // Block this node precommitted; it prevotes nothing else
+	lockedRound int
+	validHash   string // Latest block with 2/3 prevotes; the proposer proposes it again
+	validRound  int
+	committed   bool
+	decided     chan Block // Set while this node's proposer waits for its block to commit
+}
+
+// NewBFT starts voting at the height after the chain's head
+func NewBFT(bc *Blockchain, pod *PoDConsensus) *BFT {
+	bft := &BFT{bc: bc, pod: pod, Timeouts: DefaultBFTTimeouts()}
+	bft.reset(bc.Chain[len(bc.Chain)-1])
+	return bft
+}
+
+// reset starts a new height on top of the canonical head. Caller must hold bft.mu.
+func (bft *BFT) reset(head Block) {
+	bft.height = head.Index + 1
+	bft.prevHash = head.Hash
+	bft.state = bft.bc.State()
+	bft.round = 0
+	bft.lastRound = -1
+	bft.stalled = false
+	bft.timed = make(map[int]bool)
+	bft.proposals = make(map[int]Block)
+	bft.checked = make(map[string]error)
+	bft.votes = make(map[voteKey]map[string]Vote)
+	bft.voted = make(map[voteKey]bool)
+	bft.lockedHash, bft.lockedRound = "", -1
+	bft.validHash, bft.validRound = "", -1
+	bft.committed = false
+}
+
+// advance moves voting to the height after a new head
+func (bft *BFT) advance(head Block) {
+	bft.mu.Lock()
+	defer bft.mu.Unlock()
+	if head.Index+1 != bft.height || head.Hash != bft.prevHash {
+		bft.reset(head)
+	}
+}
+
+// Start keeps the rounds of the current height running for up to maxRounds more rounds, so
+// the height moves on to the next proposer if the scheduled one stays silent, and returns
+// the round to propose in. If the last round already timed out, the next round starts.
+func (bft *BFT) Start() int {
+	bft.mu.Lock()
+	defer bft.mu.Unlock()
+
+	if bft.stalled {
+		bft.round++
+		bft.stalled = false
+	}
+	bft.extend(bft.round)
+	return bft.round
+}
+
+// extend lets the rounds run until maxRounds rounds after round and starts the timeout of
+// the current round. Caller must hold bft.mu.
+func (bft *BFT) extend(round int) {
+	if last := round + maxRounds - 1; last > bft.lastRound {
+		bft.lastRound = last
+	}
+	if bft.timed[bft.round] {
+		return
+	}
+	bft.timed[bft.round] = true
+	height, current := bft.height, bft.round
+	time.AfterFunc(bft.Timeouts.forRound(current), func() { bft.roundTimeout(height, current) })
+}
+
+// roundTimeout starts the round after one that did not commit in time; its scheduled
+// proposer proposes if it is this node's validator
+func (bft *BFT) roundTimeout(height int, round int) {
+	bft.mu.Lock()
+	if height != bft.height || round != bft.round || bft.committed {
+		bft.mu.Unlock()
+		return
+	}
+	fmt.Printf("⏰ Round %d of Block #%d timed out\n", round, height)
+	if round >= bft.lastRound {
+		bft.stalled = true
+		bft.mu.Unlock()
+		fmt.Printf("⏸ Block #%d not committed by round %d; mining again starts the next round\n", height, round)
+		return
+	}
+
+	next := round + 1
+	bft.round = next
+	bft.extend(next)
+	local := bft.activeLocal(bft.state)
+	proposer := bft.pod.SelectProposer(bft.state, bft.prevHash, next)
+	block, valid := bft.blockByHash(bft.validHash)
+	bft.mu.Unlock()
+
+	if local == nil || proposer == nil || proposer.ID != local.ID {
+		return
+	}
+	// ✅ A block that had 2/3 prevotes is proposed again; otherwise this node builds its own
+	if !valid {
+		built, err := bft.bc.buildBlock(next)
+		if err != nil {
+			fmt.Printf("⏭ Nothing to propose for Block #%d in round %d: %v\n", height, next, err)
+			return
+		}
+		block = built
+	}
+	bft.propose(next, block)
+}
+
+// localValidator returns the validator this node votes as, or nil (also when it is jailed)
+func (bft *BFT) localValidator() *Validator {
+	bft.mu.Lock()
+	state := bft.state
+	bft.mu.Unlock()
+
+	return bft.activeLocal(state)
+}
+
+// activeLocal returns the validator this node votes as if it is active after state, or nil
+func (bft *BFT) activeLocal(state *State) *Validator {
+	for _, v := range bft.pod.validators.Active(state) {
+		if v.Signer != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// Propose proposes a block this node built for the current round and waits until its height
+// is committed: by this block, or by the block of a later round's proposer. Caller must not
+// hold bc.mu, which adding blocks from other nodes takes.
+func (bft *BFT) Propose(block *Block) error {
+	decided := make(chan Block, 1)
+	bft.mu.Lock()
+	if block.Index != bft.height || block.PreviousHash != bft.prevHash {
+		bft.mu.Unlock()
+		return fmt.Errorf("block #%d is not on top of the head being voted on", block.Index)
+	}
+	round, lastRound := bft.round, bft.lastRound
+	if proposer := bft.pod.SelectProposer(bft.state, bft.prevHash, round); proposer == nil || proposer.Address != block.Proposer {
+		bft.mu.Unlock()
+		return fmt.Errorf("%w: voting on block #%d moved on to round %d", ErrNotProposer, block.Index, round)
+	}
+	// ✅ A block that had 2/3 prevotes in an earlier round is proposed again instead
+	if valid, ok := bft.blockByHash(bft.validHash); ok {
+		*block = valid
+	}
+	bft.decided = decided
+	bft.mu.Unlock()
+
+	bft.propose(round, *block)
+
+	select {
+	case committed := <-decided:
+		bft.stopProposing()
+		*block = committed
+		return nil
+	case <-time.After(bft.Timeouts.until(round, lastRound)):
+	}
+
+	// ✅ A commit handed over while the last round timed out still counts
+	if committed, ok := bft.stopProposing(); ok {
+		*block = committed
+		return nil
+	}
+	return fmt.Errorf("block #%d was not committed by round %d", block.Index, lastRound)
+}
+
+// propose broadcasts a block this node's validator proposes in a round and prevotes on it
+func (bft *BFT) propose(round int, block Block) {
+	bft.mu.Lock()
+	if block.Index != bft.height || block.PreviousHash != bft.prevHash || round != bft.round {
+		bft.mu.Unlock()
+		return
+	}
+	bft.checked[block.Hash] = nil // ✅ Built by this node on its own head, or validated before
+	bft.mu.Unlock()
+
+	fmt.Printf("📢 Proposing Block #%d in round %d\n", block.Index, round)
+	if bft.bc.Network != nil {
+		bft.bc.Network.BroadcastProposal(round, block)
+	}
+	bft.onProposal(round, block)
+}
+
+// stopProposing ends Propose's wait; a block committed afterwards is added with AcceptBlock.
+// It returns the block committed before, if it was not received yet.
+func (bft *BFT) stopProposing() (Block, bool) {
+	bft.mu.Lock()
+	defer bft.mu.Unlock()
+
+	decided := bft.decided
+	bft.decided = nil
+	select {
+	case committed := <-decided:
+		return committed, true
+	default:
+		return Block{}, false
+	}
+}
+
+// HandleProposal validates a block proposed by a peer and votes on it
+func (bft *BFT) HandleProposal(round int, block Block) error {
+	bft.mu.Lock()
+	height, prevHash, state, current := bft.height, bft.prevHash, bft.state, bft.round
+	_, checked := bft.checked[block.Hash]
+	bft.mu.Unlock()
+	if block.Index != height {
+		return fmt.Errorf("proposal for height %d, voting on %d", block.Index, height)
+	}
+	if round < 0 || round >= current+maxRounds {
+		return fmt.Errorf("round %d out of range", round)
+	}
+	// ✅ Only the round's proposer may propose, or re-propose the block of an earlier round's
+	if err := bft.bc.checkProposer(block, prevHash, state, round); err != nil {
+		return err
+	}
+
+	// ✅ Validation takes bc.mu, so it must run without holding bft.mu
+	if !checked {
+		err := bft.bc.validateProposedBlock(block)
+		if err != nil {
+			fmt.Printf("❌ Invalid proposal for Block #%d: %v\n", block.Index, err)
+		}
+		bft.mu.Lock()
+		if block.Index == bft.height {
+			bft.checked[block.Hash] = err
+		}
+		bft.mu.Unlock()
+	}
+
+	bft.onProposal(round, block)
+	return nil
+}
+
+// onProposal records a proposal and prevotes. The block must have been validated.
+func (bft *BFT) onProposal(round int, block Block) {
+	local := bft.localValidator()
+
+	bft.mu.Lock()
+	if block.Index != bft.height {
+		bft.mu.Unlock()
+		return
+	}
+	if existing, ok := bft.proposals[round]; ok && existing.Hash != block.Hash {
+		bft.mu.Unlock()
+		fmt.Printf("⚠ Conflicting proposals for Block #%d in round %d, keeping the first\n", block.Index, round)
+		return
+	}
+	bft.proposals[round] = block
+	if round > bft.round {
+		bft.round, bft.stalled = round, false
+	}
+	bft.extend(round)
+
+	prevote := local != nil && round == bft.round && !bft.voted[voteKey{round, VotePrevote}]
+	hash := ""
+	if prevote {
+		bft.voted[voteKey{round, VotePrevote}] = true
+		if bft.checked[block.Hash] == nil && bft.canPrevote(block.Hash) {
+			if local.ApproveBlock(&block, bft.bc) {
+				hash = block.Hash
+			}
+		}
+	}
+	actions := bft.evaluate(round)
+	bft.mu.Unlock()
+
+	if prevote {
+		bft.castVote(local, VotePrevote, block.Index, round, hash)
+		time.AfterFunc(bft.Timeouts.Prevote, func() { bft.prevoteTimeout(block.Index, round) })
+	}
+	bft.perform(actions)
+}
+
+// canPrevote applies the lock: a validator that precommitted a block prevotes only for it,
+// unless a later round reached 2/3 prevotes for another block. Caller must hold bft.mu.
+func (bft *BFT) canPrevote(hash string) bool {
+	if bft.lockedHash == "" || bft.lockedHash == hash {
+		return true
+	}
+	return hash == bft.validHash && bft.validRound > bft.lockedRound
+}
+
+// prevoteTimeout precommits nil if the round did not reach 2/3 prevotes in time
+func (bft *BFT) prevoteTimeout(height int, round int) {
+	local := bft.localValidator()
+
+	bft.mu.Lock()
+	key := voteKey{round, VotePrecommit}
+	expired := local != nil && height == bft.height && !bft.committed && !bft.voted[key]
+	if expired {
+		bft.voted[key] = true
+	}
+	bft.mu.Unlock()
+
+	if expired {
+		fmt.Printf("⏰ No 2/3 prevotes for Block #%d in round %d, precommitting nil\n", height, round)
+		bft.castVote(local, VotePrecommit, height, round, "")
+	}
+}
+
+// castVote signs a vote as the local validator, counts it and gossips it
+func (bft *BFT) castVote(local *Validator, voteType VoteType, height int, round int, hash string) {
+	vote := Vote{Type: voteType, Height: height, Round: round, BlockHash: hash}
+	if err := local.SignVote(&vote); err != nil {
+		fmt.Printf("❌ Failed to sign %s: %v\n", voteType, err)
+		return
+	}
+	target := hash
+	if target == "" {
+		target = "nil"
+	}
+	fmt.Printf("🗳 Validator %s %ss %s for Block #%d (round %d)\n", local.ID, voteType, target, height, round)
+	if err := bft.HandleVote(vote); err != nil {
+		fmt.Println("❌ Own vote rejected:", err)
+	}
+}
+
+// HandleVote verifies a vote from this node or a peer, counts it, gossips it to our
+// peers if it is new and acts on the quorums it completes
+func (bft *BFT) HandleVote(vote Vote) error {
+	validator := bft.pod.validators.ByID(vote.ValidatorID)
+	if validator == nil {
+		return fmt.Errorf("vote from unknown validator %s", vote.ValidatorID)
+	}
+	if !validator.VerifyVote(vote) {
+		return fmt.Errorf("invalid vote signature from validator %s", vote.ValidatorID)
+	}
+
+	bft.mu.Lock()
+	if vote.Height != bft.height || vote.Round < 0 || vote.Round >= bft.round+maxRounds {
+		bft.mu.Unlock()
+		return nil // ✅ Not for the height being decided; blocks of other heights arrive whole
+	}
+	if record, _ := bft.state.Validator(vote.ValidatorID); record.Jailed {
+		bft.mu.Unlock()
+		return fmt.Errorf("vote from jailed validator %s", vote.ValidatorID)
+	}
+	key := voteKey{vote.Round, vote.Type}
+	if bft.votes[key] == nil {
+		bft.votes[key] = make(map[string]Vote)
+	}
+	if existing, ok := bft.votes[key][vote.ValidatorID]; ok {
+		bft.mu.Unlock()
+		if existing.BlockHash != vote.BlockHash {
+			// ✅ Two signed votes for one step prove double signing; the first one keeps counting
+			fmt.Printf("🚨 Validator %s cast conflicting %ss in round %d of height %d\n", vote.ValidatorID, vote.Type, vote.Round, vote.Height)
+			evidence, err := NewDuplicateVoteEvidence(existing, vote)
+			if err == nil {
+				err = bft.bc.SubmitEvidence(evidence)
+			}
+			if err != nil {
+				fmt.Println("⚠ Evidence not submitted:", err)
+				return nil
+			}
+			// ✅ Gossip the conflicting vote once, so every node can submit the evidence
+			if bft.bc.Network != nil {
+				bft.bc.Network.BroadcastVote(vote)
+			}
+		}
+		return nil
+	}
+	bft.votes[key][vote.ValidatorID] = vote
+	actions := bft.evaluate(vote.Round)
+	bft.mu.Unlock()
+
+	if bft.bc.Network != nil {
+		bft.bc.Network.BroadcastVote(vote)
+	}
+	bft.perform(actions)
+	return nil
+}
+
+// bftActions are the steps a quorum triggers, performed after releasing bft.mu
+type bftActions struct {
+	precommit *Vote // Precommit to cast as the local validator
+	local     *Validator
+	commit    *Block // Block that became final
+}
+
+// evaluate checks the votes of a round for quorums. Caller must hold bft.mu.
+func (bft *BFT) evaluate(round int) bftActions {
+	var actions bftActions
+
+	// ✅ More than 2/3 prevoted one block (or nil): precommit it and lock on it
+	if hash, ok := bft.quorum(voteKey{round, VotePrevote}); ok {
+		if hash != "" && round > bft.validRound {
+			bft.validHash, bft.validRound = hash, round
+		}
+		key := voteKey{round, VotePrecommit}
+		local := bft.activeLocal(bft.state)
+		if local != nil && round == bft.round && bft.voted[voteKey{round, VotePrevote}] && !bft.voted[key] {
+			_, known := bft.proposals[round]
+			if hash == "" || (known && bft.proposals[round].Hash == hash) {
+				bft.voted[key] = true
+				if hash != "" {
+					bft.lockedHash, bft.lockedRound = hash, round
+				}
+				actions.local = local
+				actions.precommit = &Vote{Type: VotePrecommit, Height: bft.height, Round: round, BlockHash: hash}
+			}
+		}
+	}
+
+	// ✅ More than 2/3 precommitted one block: it is final
+	if hash, ok := bft.quorum(voteKey{round, VotePrecommit}); ok && hash != "" && !bft.committed {
+		if block, known := bft.blockByHash(hash); known {
+			bft.committed = true
+			block.Commit = bft.certificate(round, hash)
+			actions.commit = &block
+		}
+	}
+	return actions
+}
+
+// perform carries out the actions of evaluate
+func (bft *BFT) perform(actions bftActions) {
+	if actions.precommit != nil {
+		vote := actions.precommit
+		bft.castVote(actions.local, vote.Type, vote.Height, vote.Round, vote.BlockHash)
+	}
+	if actions.commit == nil {
+		return
+	}
+
+	block := *actions.commit
+	fmt.Printf("🏁 Block #%d committed in round %d with %d precommits\n", block.Index, block.Commit.Round, len(block.Commit.Precommits))
+
+	// ✅ While MineBlock waits for its proposal, it adds and broadcasts the block
+	bft.mu.Lock()
+	if bft.decided != nil {
+		bft.decided <- block
+		bft.mu.Unlock()
+		return
+	}
+	bft.mu.Unlock()
+	if err := bft.bc.AcceptBlock(block); err != nil {
+		fmt.Printf("❌ Failed to add committed Block #%d: %v\n", block.Index, err)
+	}
+}
+
+// quorum returns the block hash (or "" for nil) that more than 2/3 of the voting power
+// voted for in a step. Caller must hold bft.mu.
+func (bft *BFT) quorum(key voteKey) (string, bool) {
+	byHash := make(map[string][]string)
+	for id, vote := range bft.votes[key] {
+		byHash[vote.BlockHash] = append(byHash[vote.BlockHash], id)
+	}
+	for hash, ids := range byHash {
+		if bft.pod.HasQuorum(bft.state, ids) {
+			return hash, true
+		}
+	}
+	return "", false
+}
+
+// certificate collects the precommits for a block in a round, ordered by validator ID.
+// Caller must hold bft.mu.
+func (bft *BFT) certificate(round int, hash string) *CommitCertificate {
+	commit := &CommitCertificate{Round: round}
+	for _, vote := range bft.votes[voteKey{round, VotePrecommit}] {
+		if vote.BlockHash == hash {
+			commit.Precommits = append(commit.Precommits, vote)
+		}
+	}
+	sort.Slice(commit.Precommits, func(i, j int) bool {
+		return commit.Precommits[i].ValidatorID < commit.Precommits[j].ValidatorID
+	})
+	return commit
+}
+
+// blockByHash returns a valid proposal of the current height. Caller must hold bft.mu.
+func (bft *BFT) blockByHash(hash string) (Block, bool) {
+	for _, block := range bft.proposals {
+		if hash != "" && block.Hash == hash && bft.checked[hash] == nil {
+			return block, true
+		}
+	}
+	return Block{}, false
+}
+
+// BFTStatus describes the voting in progress
+type BFTStatus struct {
+	Height      int            `json:"height"`
+	Round       int            `json:"round"`
+	Proposals   map[int]string `json:"proposals"`    // Proposed block hash of each round
+	LockedHash  string         `json:"locked_hash"`  // Block this node precommitted
+	LockedRound int            `json:"locked_round"` // -1 when not locked
+	Votes       []Vote         `json:"votes"`
+}
+
+// Status returns the state of the current height's voting
+func (bft *BFT) Status() BFTStatus {
+	bft.mu.Lock()
+	defer bft.mu.Unlock()
+
+	status := BFTStatus{
+		Height:      bft.height,
+		Round:       bft.round,
+		Proposals:   make(map[int]string),
+		LockedHash:  bft.lockedHash,
+		LockedRound: bft.lockedRound,
+		Votes:       []Vote{},
+	}
+	for round, block := range bft.proposals {
+		status.Proposals[round] = block.Hash
+	}
+	for _, votes := range bft.votes {
+		for _, vote := range votes {
+			status.Votes = append(status.Votes, vote)
+		}
+	}
+	sort.Slice(status.Votes, func(i, j int) bool {
+		a, b := status.Votes[i], status.Votes[j]
+		if a.Round != b.Round {
+			return a.Round < b.Round
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.ValidatorID < b.ValidatorID
+	})
+	return status
+}
@@ -0,0 +1,131 @@
+package blockchain
+
+import (
+	"testing"
+)
+
+func TestBFTLock(t *testing.T) {
+	// ✅ Four equal validators: this node votes as v0, and v1 to v3 alone are more than 2/3
+	genesis, keys := testGenesisKeys(t, []int64{100, 100, 100, 100})
+	hashA, hashB, hashC := testPrevHash(1), testPrevHash(2), testPrevHash(3)
+
+	type polka struct {
+		round    int
+		hash     string // Block prevoted, "" for nil
+		voters   int    // Peers among v1 to v3 that prevote it
+		prevoted bool   // Whether this node prevoted in the round
+	}
+	tests := []struct {
+		name        string
+		polkas      []polka
+		wantLocked  string
+		wantRound   int
+		canPrevote  []string
+		cantPrevote []string
+	}{
+		{
+			name:       "no quorum",
+			polkas:     []polka{{round: 0, hash: hashA, voters: 2, prevoted: true}},
+			wantRound:  -1,
+			canPrevote: []string{hashA, hashB},
+		},
+		{
+			name:        "locks on a polka it prevoted",
+			polkas:      []polka{{round: 0, hash: hashA, voters: 3, prevoted: true}},
+			wantLocked:  hashA,
+			wantRound:   0,
+			canPrevote:  []string{hashA},
+			cantPrevote: []string{hashB},
+		},
+		{
+			name:       "polka it did not prevote in",
+			polkas:     []polka{{round: 0, hash: hashA, voters: 3}},
+			wantRound:  -1,
+			canPrevote: []string{hashA, hashB},
+		},
+		{
+			name:       "nil polka",
+			polkas:     []polka{{round: 0, voters: 3, prevoted: true}},
+			wantRound:  -1,
+			canPrevote: []string{hashA, hashB},
+		},
+		{
+			name:        "later polka unlocks",
+			polkas:      []polka{{round: 0, hash: hashA, voters: 3, prevoted: true}, {round: 1, hash: hashB, voters: 3}},
+			wantLocked:  hashA,
+			wantRound:   0,
+			canPrevote:  []string{hashA, hashB},
+			cantPrevote: []string{hashC},
+		},
+		{
+			name:        "later polka it prevoted relocks",
+			polkas:      []polka{{round: 0, hash: hashA, voters: 3, prevoted: true}, {round: 1, hash: hashB, voters: 3, prevoted: true}},
+			wantLocked:  hashB,
+			wantRound:   1,
+			canPrevote:  []string{hashB},
+			cantPrevote: []string{hashA, hashC},
+		},
+		{
+			name:        "earlier polka does not unlock",
+			polkas:      []polka{{round: 1, hash: hashA, voters: 3, prevoted: true}, {round: 0, hash: hashB, voters: 3}},
+			wantLocked:  hashA,
+			wantRound:   1,
+			canPrevote:  []string{hashA},
+			cantPrevote: []string{hashB},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := NewPoDConsensusFromGenesis(genesis)
+			if err := pod.Validators().AttachKey("v0", keys[0]); err != nil {
+				t.Fatal(err)
+			}
+			head := genesis.Block()
+			bc := &Blockchain{Genesis: genesis}
+			bc.setHead([]Block{head}, NewState(genesis))
+			bft := &BFT{bc: bc, pod: pod, Timeouts: DefaultBFTTimeouts()}
+			bft.reset(head)
+
+			for _, p := range tt.polkas {
+				bft.mu.Lock()
+				bft.round = p.round
+				if p.hash != "" {
+					bft.proposals[p.round] = Block{BlockHeader: BlockHeader{Index: 1, PreviousHash: head.Hash}, Hash: p.hash}
+				}
+				if p.prevoted {
+					bft.voted[voteKey{p.round, VotePrevote}] = true
+				}
+				bft.mu.Unlock()
+
+				for i := 1; i <= p.voters; i++ {
+					validator := &Validator{ID: genesis.Validators[i].ID, Signer: keys[i]}
+					vote := Vote{Type: VotePrevote, Height: 1, Round: p.round, BlockHash: p.hash}
+					if err := validator.SignVote(&vote); err != nil {
+						t.Fatal(err)
+					}
+					if err := bft.HandleVote(vote); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			status := bft.Status()
+			if status.LockedHash != tt.wantLocked || status.LockedRound != tt.wantRound {
+				t.Fatalf("locked on %q in round %d, expected %q in round %d", status.LockedHash, status.LockedRound, tt.wantLocked, tt.wantRound)
+			}
+			bft.mu.Lock()
+			defer bft.mu.Unlock()
+			for _, hash := range tt.canPrevote {
+				if !bft.canPrevote(hash) {
+					t.Errorf("cannot prevote %s", hash)
+				}
+			}
+			for _, hash := range tt.cantPrevote {
+				if bft.canPrevote(hash) {
+					t.Errorf("can prevote %s", hash)
+				}
+			}
+		})
+	}
+}
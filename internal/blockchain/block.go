@@ -3,46 +3,73 @@ package blockchain
 import (
 	"crypto/sha256"
 	"encoding/hex"
-	"fmt"
 	"time"
 )
 
+// BlockHeader holds the block fields committed to by the block hash; which of them
+// are hashed depends on Version (see docs/encoding.md)
+type BlockHeader struct {
+	Version      uint32 // Header version, fixed by the rules active at Index
+	ChainID      string // Network the block belongs to (version 2+)
+	Index        int    // Block position in the chain
+	Timestamp    string // Block creation timestamp
+	PreviousHash string // Hash of the previous block
+	MerkleRoot   string // Merkle root over all transaction IDs
+	Proposer     string // Address of the block signer (version 2+)
+	StateRoot    string // Root of the state after the block (version 4+, see State.Root)
+}
+
 // Block represents a single block in the blockchain
 type Block struct {
-	Index        int            // Block position in the chain
-	Timestamp    string         // Block creation timestamp
-	Transactions []Transaction  // Transactions stored in the block
-	PreviousHash string         // Hash of the previous block
-	Hash         string         // Unique block hash
-	Signature    string         // Digital signature for authenticity
+	BlockHeader
+	Transactions []Transaction      // Transactions stored in the block
+	Hash         string             // Unique block hash
+	Signature    string             // Digital signature for authenticity
+	KeyType      KeyType            // Signature scheme of the block signer
+	PublicKey    string             // Public key of the block signer (hex)
+	Commit       *CommitCertificate // Precommits that made the block final (PoD only)
+	Approvals    []Approval         `json:",omitempty"` // Validator signatures that made PoD blocks final before commit certificates
+}
+
+// Approval is a validator's signature over a block hash or TxID. PoD blocks carried them
+// before the rules required a commit certificate (see BlockRules.CommitCertificate); file
+// notarizations carry those of the validators that checked the file.
+type Approval struct {
+	ValidatorID string // ID of the approving validator
+	Signature   string // Signature over the block hash or TxID with the validator's key
 }
 
-// NewBlock creates a new block containing validated transactions
-func NewBlock(index int, transactions []Transaction, previousHash string, wallet *Wallet) Block {
-	timestamp := time.Now().UTC().Format(time.RFC3339)
-	hash := calculateHash(index, timestamp, transactions, previousHash)
+// NewBlock creates a new block containing validated transactions. The header's
+// version, chain ID, index, previous hash and state root are given (see
+// ChainConfig.NewHeader); timestamp, Merkle root and proposer are filled in here.
+func NewBlock(header BlockHeader, transactions []Transaction, wallet *Wallet) Block {
+	header.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	header.MerkleRoot = MerkleRoot(transactions)
+	if header.Version >= BlockVersion2 {
+		header.Proposer = wallet.Address()
+	}
+	hash, _ := header.ComputeHash()
 
 	// Sign the block hash using the wallet
 	signature, _ := wallet.SignData(hash)
 
 	return Block{
-		Index:        index,
-		Timestamp:    timestamp,
+		BlockHeader:  header,
 		Transactions: transactions,
-		PreviousHash: previousHash,
 		Hash:         hash,
 		Signature:    signature,
+		KeyType:      wallet.KeyType(),
+		PublicKey:    wallet.PublicKeyHex(),
 	}
 }
 
-// calculateHash generates a SHA-256 hash for the block
-func calculateHash(index int, timestamp string, transactions []Transaction, previousHash string) string {
-	txID := "GENESIS" // Default TxID for empty transactions
-	if len(transactions) > 0 {
-		txID = transactions[0].TxID // Prevent index out of range
+// ComputeHash generates a SHA-256 hash over the canonical encoding of the header
+// ✅ The Merkle root commits to every transaction, not just the first one
+func (h BlockHeader) ComputeHash() (string, error) {
+	encoded, err := h.Bytes()
+	if err != nil {
+		return "", err
 	}
-
-	input := fmt.Sprintf("%d%s%s%s", index, timestamp, txID, previousHash)
-	hash := sha256.Sum256([]byte(input))
-	return hex.EncodeToString(hash[:])
+	hash := sha256.Sum256(encoded)
+	return hex.EncodeToString(hash[:]), nil
 }
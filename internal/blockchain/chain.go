@@ -1,27 +1,138 @@
 package blockchain
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
 )
 
-// Blockchain represents a list of blocks with PoD consensus, P2P networking, and a Mempool
+// ErrNoTransactions is returned when mining with an empty mempool
+var ErrNoTransactions = errors.New("no transactions in mempool to mine")
+
+// Blockchain represents a list of blocks with a consensus engine, P2P networking, and a Mempool
 type Blockchain struct {
-	Chain     []Block       `json:"chain"`      // List of blocks in the blockchain
-	Mempool   *Mempool      `json:"-"`          // ✅ Use separate mempool struct
-	Consensus *PoDConsensus `json:"consensus"`  // Consensus mechanism
-	Network   *P2PNetwork   `json:"-"`          // P2P network (excluded from JSON)
+	Chain       []Block         `json:"chain"`      // List of blocks in the blockchain
+	Config      ChainConfig     `json:"-"`          // Chain ID, parameters and rule upgrade schedule
+	Genesis     *Genesis        `json:"-"`          // Genesis file the chain starts from
+	GenesisHash string          `json:"-"`          // Hash of the genesis block
+	Mempool     *Mempool        `json:"-"`          // ✅ Use separate mempool struct
+	Consensus   ConsensusEngine `json:"-"`          // Consensus engine selected in the genesis file
+	BFT         *BFT            `json:"-"`          // Voting rounds with the other validator nodes (PoD only)
+	Network     *P2PNetwork     `json:"-"`          // P2P network (excluded from JSON)
+	Store       *BlockStore     `json:"-"`          // Durable on-disk block log
+	Files       *FileStore      `json:"-"`          // Content-addressed store of uploaded files
+	TrustScorer TrustScorer     `json:"-"`          // Proof-of-Data scoring of uploaded files
+	Admission   *Admission      `json:"-"`          // Rules transactions must pass to enter the mempool
+	Tree        *BlockTree      `json:"-"`          // Every known block, including side branches
+	Reorgs      *ReorgFeed      `json:"-"`          // Chain reorganization events
+	mu          sync.Mutex                          // Serializes changes to the canonical chain
+	state       *State                              // State after the canonical chain (see State)
+	stateMu     sync.RWMutex                        // Guards state and writes of Chain
 }
 
-// NewBlockchain initializes the blockchain with PoD consensus, P2P networking, and an empty mempool
-func NewBlockchain(port string) *Blockchain {
-	genesisWallet := NewWallet()
-	genesisBlock := NewBlock(0, []Transaction{}, "0", genesisWallet)
-	pod := NewPoDConsensus()
+// NewBlockchain loads the chain from dataDir and initializes the consensus engine, P2P networking,
+// and an empty mempool. A data directory without a genesis file is initialized as a new
+// single-validator development network (see InitDevDataDir), unless it already holds a chain
+// from before genesis files (see MigrateLegacyDataDir).
+func NewBlockchain(port string, dataDir string) (*Blockchain, error) {
+	if IsLegacyDataDir(dataDir) {
+		return nil, fmt.Errorf("%w: run \"migrate %s\" to archive it and start a new network", ErrLegacyDataDir, dataDir)
+	}
+
+	genesisPath := filepath.Join(dataDir, GenesisFileName)
+	if _, err := os.Stat(genesisPath); errors.Is(err, os.ErrNotExist) {
+		fmt.Println("🌱 No genesis file in", dataDir, "- creating a development network")
+		if _, err := InitDevDataDir(dataDir, EnginePoD); err != nil {
+			return nil, err
+		}
+	}
+
+	genesis, err := LoadGenesis(genesisPath)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := OpenBlockStore(dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// ✅ Every node of the network derives the same genesis block from the genesis file
+	genesisBlock := genesis.Block()
+	if store.Len() == 0 {
+		if err := store.Append(genesisBlock); err != nil {
+			store.Close()
+			return nil, err
+		}
+	}
+
+	chain, err := store.LoadChain()
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	fmt.Printf("📦 Loaded %d block(s) from %s\n", len(chain), dataDir)
+
+	files, err := OpenFileStore(dataDir)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+
+	engine, err := NewConsensusEngine(genesis)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	fmt.Printf("⚙ Consensus engine: %s\n", engine.Name())
+
+	// ✅ A validator node signs with its key from the data directory
+	if id, signer, err := LoadValidatorKey(dataDir); err == nil {
+		if err := engine.Validators().AttachKey(id, signer); err != nil {
+			store.Close()
+			return nil, err
+		}
+		fmt.Println("🔑 Running as validator", id)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		store.Close()
+		return nil, err
+	}
 
 	bc := &Blockchain{
-		Chain:     []Block{genesisBlock},
-		Mempool:   NewMempool(), // ✅ Use the new Mempool struct
-		Consensus: pod,
+		Chain:       chain,
+		Config:      genesis.Config(),
+		Genesis:     genesis,
+		GenesisHash: genesisBlock.Hash,
+		Mempool:     NewMempool(), // ✅ Use the new Mempool struct
+		Consensus:   engine,
+		Store:       store,
+		Files:       files,
+		TrustScorer: NewContentTrustScorer(),
+		Admission:   NewAdmission(),
+		Tree:        NewBlockTree(chain),
+		Reorgs:      NewReorgFeed(),
+	}
+	bc.Mempool.OnDrop = bc.releaseFile // ✅ Content of uploads that are never mined is not kept
+
+	// ✅ Never start on top of a corrupted or tampered chain, or one from another network
+	if err := bc.ValidateChain(chain); err != nil {
+		store.Close()
+		return nil, fmt.Errorf("stored chain is invalid: %w", err)
+	}
+	state, err := bc.replayState(chain)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	bc.setHead(chain, state)
+	bc.Tree.SetState(chain[len(chain)-1].Hash, state)
+
+	// ✅ PoD blocks are committed by voting with the other validator nodes
+	if pod, ok := engine.(*PoDConsensus); ok {
+		bc.BFT = NewBFT(bc, pod)
+		pod.voting = bc.BFT
 	}
 
 	// ✅ Ensure Network field is properly initialized only if not already connected
@@ -29,51 +140,205 @@ func NewBlockchain(port string) *Blockchain {
 		bc.Network = NewP2PNetwork(bc, port)
 	}
 
-	return bc
+	return bc, nil
 }
 
-// AddTransaction sends transactions to the mempool (NOT directly to the blockchain)
-func (bc *Blockchain) AddTransaction(tx Transaction) {
-	// ✅ Prevent duplicate transactions
-	for _, existingTx := range bc.Mempool.GetTransactions() {
-		if existingTx.TxID == tx.TxID {
-			fmt.Println("⚠ Transaction already exists in mempool:", tx.TxID)
-			return
-		}
+// releaseFile drops the reference of a file notarization that is no longer pending nor mined
+// (dropped from the mempool, or orphaned and not requeued) to its stored content, which is
+// deleted once nothing references it
+func (bc *Blockchain) releaseFile(tx Transaction) {
+	if !tx.isFile() || bc.Files == nil {
+		return
 	}
+	if err := bc.Files.Release(tx.File.FileHash, tx.TxID); err != nil && !errors.Is(err, ErrFileNotFound) {
+		fmt.Println("⚠ Failed to release file reference:", err)
+	}
+}
 
-	bc.Mempool.AddTransaction(tx) // ✅ Use Mempool struct instead of direct array
-	fmt.Printf("✅ Transaction added to mempool: %s\n", tx.TxID)
+// moveFileRef hands the reference of an orphaned file notarization to its stored content over
+// to the adopted transaction notarizing the same file
+func (bc *Blockchain) moveFileRef(tx Transaction, adoptedTxID string) {
+	if tx.TxID == adoptedTxID || bc.Files == nil {
+		return
+	}
+	if err := bc.Files.AddRef(tx.File.FileHash, adoptedTxID); err != nil {
+		if !errors.Is(err, ErrFileNotFound) {
+			fmt.Println("⚠ Failed to record file reference:", err)
+		}
+		return
+	}
+	bc.releaseFile(tx)
 }
 
-// MineBlock moves transactions from mempool to a new block
-func (bc *Blockchain) MineBlock(wallet *Wallet) *Block {
-	transactions := bc.Mempool.GetTransactions() // ✅ Get transactions from mempool
-	if len(transactions) == 0 {
-		fmt.Println("⚠ No transactions in mempool to mine.")
-		return nil
+// MineBlock moves transactions from mempool to a new block. The block is signed by the
+// validator scheduled to propose it in the current voting round (see
+// ConsensusEngine.SelectProposer), so it can only be mined on the node holding that
+// validator's key; otherwise ErrNotProposer is returned. Voting on the block runs without
+// holding the chain, so the node keeps accepting blocks, votes and transactions meanwhile.
+func (bc *Blockchain) MineBlock() (*Block, error) {
+	// ✅ PoD heights rotate to the next proposer when a round times out
+	round := 0
+	if bc.BFT != nil {
+		round = bc.BFT.Start()
 	}
 
-	prevBlock := bc.Chain[len(bc.Chain)-1]
-	newBlock := NewBlock(prevBlock.Index+1, transactions, prevBlock.Hash, wallet)
+	newBlock, err := bc.buildBlock(round)
+	if err != nil {
+		return nil, err
+	}
 
-	// Validate and add block
-	if !bc.Consensus.ValidateBlock(newBlock, bc) {
+	// ✅ The consensus engine finalizes the block (e.g. validators vote to commit it)
+	if err := bc.Consensus.Finalize(&newBlock); err != nil {
 		fmt.Println("❌ Block validation failed! Not adding to blockchain.")
-		return nil
+		return nil, err
 	}
 
-	bc.Chain = append(bc.Chain, newBlock)  // ✅ Add mined block to chain
-	fmt.Printf("✅ Block #%d added with Proof-of-Data consensus!\n", newBlock.Index)
+	// ✅ Add mined block to the block tree; fork choice makes it the new head
+	bc.mu.Lock()
+	err = bc.extendTree([]Block{newBlock})
+	bc.mu.Unlock()
+	if err != nil {
+		fmt.Println("❌ Failed to add block:", err)
+		return nil, err
+	}
+	fmt.Printf("✅ Block #%d added with %s consensus!\n", newBlock.Index, bc.Consensus.Name())
+	if coinbase := newBlock.Transactions[0].Coinbase; coinbase != nil {
+		for _, payout := range coinbase.Payouts {
+			fmt.Printf("💰 %s earned %d QRY tokens!\n", payout.Address, payout.Amount)
+		}
+	}
+	if fees := blockFees(newBlock); fees > 0 {
+		fmt.Printf("💰 %s earned %d QRY in fees!\n", newBlock.Proposer, fees)
+	}
 
-	bc.Mempool.Clear() // ✅ Clear mempool after block is added
+	bc.Mempool.RemoveTransactions(newBlock.Transactions) // ✅ Transactions left over wait for the next block
 
-	// ✅ Ensure `Network` is not nil before calling `BroadcastBlockchain`
+	// ✅ Ensure `Network` is not nil before calling `BroadcastBlock`
 	if bc.Network != nil {
-		bc.Network.BroadcastBlockchain()
+		bc.Network.BroadcastBlock(newBlock)
 	} else {
 		fmt.Println("⚠ Warning: Network is not initialized. Skipping broadcast.")
 	}
 
-	return &newBlock // ✅ Return newly mined block
+	return &newBlock, nil // ✅ Return newly mined block
+}
+
+// buildBlock builds and signs the block on top of the head that this node's validator
+// proposes in a voting round
+func (bc *Blockchain) buildBlock(round int) (Block, error) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if bc.Mempool.Len() == 0 {
+		fmt.Println("⚠ No transactions in mempool to mine.")
+		return Block{}, ErrNoTransactions
+	}
+
+	prevBlock := bc.Chain[len(bc.Chain)-1]
+	height := prevBlock.Index + 1
+
+	// ✅ Every node derives who proposes the block at this height from the chain
+	proposer := bc.Consensus.SelectProposer(bc.State(), prevBlock.Hash, round)
+	if proposer == nil {
+		fmt.Println("❌ No active validators! Block cannot be proposed.")
+		return Block{}, errors.New("no active validators: every validator is jailed or none is registered")
+	}
+	if proposer.Signer == nil {
+		fmt.Printf("⏳ Block #%d is proposed by validator %s in round %d, not by this node\n", height, proposer.ID, round)
+		return Block{}, fmt.Errorf("%w: block #%d is proposed by validator %s in round %d", ErrNotProposer, height, proposer.ID, round)
+	}
+	fmt.Printf("🎯 Validator %s proposes Block #%d in round %d\n", proposer.ID, height, round)
+
+	// ✅ Take transactions from the mempool that apply on top of the head, up to the maximum block size
+	transactions := bc.selectTransactions(prevBlock, proposer.Address)
+	if len(transactions) == 0 || len(transactions) == 1 && transactions[0].Coinbase != nil {
+		fmt.Println("⚠ No pending transaction applies on top of the chain.")
+		return Block{}, ErrNoTransactions
+	}
+
+	// ✅ The header commits to the state the block leads to
+	header := bc.Config.NewHeader(height, prevBlock.Hash)
+	if bc.Config.RulesAt(height).StateRoot {
+		header.Proposer = proposer.Address // ✅ Paid the fees of the block
+		state, err := ApplyBlock(bc.State(), Block{BlockHeader: header, Transactions: transactions})
+		if err != nil {
+			return Block{}, err
+		}
+		header.StateRoot = state.Root()
+	}
+
+	return NewBlock(header, transactions, &Wallet{Signer: proposer.Signer}), nil
+}
+
+// selectTransactions returns the transactions of the next block proposed by proposer (an
+// address): the coinbase, then the pending transactions paying the highest fee per byte that
+// still apply on top of the chain and fit in the block. A transaction that only applies after
+// another one (e.g. a sender's next nonce) is taken in a later pass. Transactions that do not
+// apply yet stay in the mempool.
+func (bc *Blockchain) selectTransactions(prevBlock Block, proposer string) []Transaction {
+	state := bc.State().Clone()
+	state.height, state.proposer = prevBlock.Index+1, proposer
+	rules := bc.Config.RulesAt(state.height)
+
+	var selected []Transaction
+	size := 0
+	if payouts := bc.Consensus.Rewards(prevBlock, proposer); len(payouts) > 0 {
+		coinbase := NewCoinbaseTransaction(state.height, payouts)
+		state.applyTransaction(coinbase)
+		selected = append(selected, coinbase)
+		size += coinbase.Size()
+	}
+
+	pending := bc.Mempool.ByPriority()
+	skipped := make(map[string]error)
+	for progress := true; progress; {
+		progress = false
+		var deferred []Transaction
+		for _, tx := range pending {
+			if tx.Fee > 0 && !rules.Fees {
+				skipped[tx.TxID] = fmt.Errorf("block version %d does not allow fees", rules.Version)
+				continue
+			}
+			if size+tx.Size() > bc.Config.Params.MaxBlockBytes {
+				skipped[tx.TxID] = errors.New("does not fit in the block")
+				continue
+			}
+			if err := state.applyTransaction(tx); err != nil {
+				skipped[tx.TxID] = err
+				deferred = append(deferred, tx)
+				continue
+			}
+			delete(skipped, tx.TxID)
+			selected = append(selected, tx)
+			size += tx.Size()
+			progress = true
+		}
+		pending = deferred
+	}
+
+	for txID, err := range skipped {
+		fmt.Printf("⏭ Skipping transaction %s: %v\n", txID, err)
+	}
+	return selected
+}
+
+// ReceiveChain validates a chain received from a peer, adds its unknown blocks to the
+// block tree and reorganizes onto it if it wins fork choice
+func (bc *Blockchain) ReceiveChain(chain []Block) error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if err := bc.ValidateChain(chain); err != nil {
+		return fmt.Errorf("received chain is invalid: %w", err)
+	}
+	return bc.extendTree(chain)
+}
+
+// AcceptBlock validates a block received from a peer and adds it to the block tree;
+// it becomes the head if it wins fork choice
+func (bc *Blockchain) AcceptBlock(block Block) error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	return bc.extendTree([]Block{block})
 }
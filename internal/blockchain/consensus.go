@@ -1,68 +1,233 @@
 package blockchain
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"math/big"
 )
 
-// PoDConsensus represents the Proof-of-Data consensus mechanism
+// ErrNotProposer is returned when this node does not hold the key of the scheduled block proposer
+var ErrNotProposer = errors.New("this node is not the scheduled block proposer")
+
+// PoDConsensus represents the Proof-of-Data consensus mechanism: validator nodes make
+// blocks final by voting over the network (see BFT)
 type PoDConsensus struct {
-	Validators []*Validator
+	validators *ValidatorSet
+	Params     ChainParams // Approval threshold and reward, from the genesis file
+	config     ChainConfig // Rule upgrades deciding which blocks need a commit certificate
+	voting     *BFT        // Voting rounds of this node, set once the node runs
 }
 
 // NewPoDConsensus initializes PoD with validator nodes
 func NewPoDConsensus() *PoDConsensus {
 	return &PoDConsensus{
-		Validators: []*Validator{},
+		validators: NewValidatorSet(nil),
+		Params:     DefaultChainParams(),
+	}
+}
+
+// NewPoDConsensusFromGenesis initializes PoD with the genesis validator set and parameters
+func NewPoDConsensusFromGenesis(genesis *Genesis) *PoDConsensus {
+	return &PoDConsensus{
+		validators: NewValidatorSet(genesis.NewValidators()),
+		Params:     genesis.Params,
+		config:     genesis.Config(),
 	}
 }
 
-// RegisterValidator registers a validator node (Prevents duplicates)
-func (pod *PoDConsensus) RegisterValidator(validator *Validator) {
-	// ✅ Prevent duplicate validators
-	for _, v := range pod.Validators {
-		if v.ID == validator.ID {
-			fmt.Println("⚠ Validator already registered:", validator.ID)
-			return
+// Name returns the engine name
+func (pod *PoDConsensus) Name() string {
+	return EnginePoD
+}
+
+// Validators returns the validator set
+func (pod *PoDConsensus) Validators() *ValidatorSet {
+	return pod.validators
+}
+
+// HasQuorum reports whether the validators with the given IDs hold enough voting power to
+// decide a round of the block after state: more than two-thirds of the total voting power
+// (see State.VotingPower) of the active validators, and at least the approval threshold. If
+// no validator has voting power, every validator has one vote.
+func (pod *PoDConsensus) HasQuorum(state *State, validatorIDs []string) bool {
+	validators := pod.validators.Active(state)
+	weighted := false
+	for _, v := range validators {
+		if state.VotingPower(v.ID) > 0 {
+			weighted = true
 		}
 	}
 
-	pod.Validators = append(pod.Validators, validator)
-	fmt.Println("✅ Validator registered:", validator.ID)
+	ids := make(map[string]bool)
+	for _, id := range validatorIDs {
+		ids[id] = true
+	}
+	var power, total int64
+	for _, v := range validators {
+		weight := int64(1)
+		if weighted {
+			weight = 0
+			if stake := state.VotingPower(v.ID); stake > 0 {
+				weight = stake
+			}
+		}
+		total += weight
+		if ids[v.ID] {
+			power += weight
+		}
+	}
+	return total > 0 && 3*power > 2*total && 100*power >= int64(pod.Params.ApprovalThreshold)*total
 }
 
-// ValidateBlock ensures a block is approved by validators before adding
-func (pod *PoDConsensus) ValidateBlock(block Block, blockchain *Blockchain) bool {
-	if len(pod.Validators) == 0 {
+// SelectProposer deterministically picks the proposer of the block after state, on top of
+// prevHash, weighted by voting power: the seed SHA-256(prevHash || height as uint64
+// big-endian), read as a big-endian integer modulo the total voting power, falls into one
+// active validator's share, with validators ordered by address. If no validator has voting
+// power, all weigh the same. That validator proposes in round 0; every later round moves to
+// the next validator by address, so a silent proposer does not stall the height. The
+// schedule only depends on the chain, so every node computes the same proposer and peers
+// can check who proposed a block.
+func (pod *PoDConsensus) SelectProposer(state *State, prevHash string, round int) *Validator {
+	validators := pod.validators.Active(state)
+	if len(validators) == 0 {
+		return nil
+	}
+	height := state.Height() + 1
+
+	weights := make([]int64, len(validators))
+	var total int64
+	for i, v := range validators {
+		if stake := state.VotingPower(v.ID); stake > 0 {
+			weights[i] = stake
+			total += stake
+		}
+	}
+	if total == 0 {
+		for i := range weights {
+			weights[i] = 1
+		}
+		total = int64(len(weights))
+	}
+
+	seed := sha256.Sum256(binary.BigEndian.AppendUint64([]byte(prevHash), uint64(height)))
+	target := new(big.Int).Mod(new(big.Int).SetBytes(seed[:]), big.NewInt(total)).Int64()
+	first := len(validators) - 1
+	for i := range validators {
+		if target < weights[i] {
+			first = i
+			break
+		}
+		target -= weights[i]
+	}
+	return validators[(first+round)%len(validators)]
+}
+
+// Finalize runs voting rounds with the validator nodes of the network until the block is
+// committed, and attaches the commit certificate to it
+func (pod *PoDConsensus) Finalize(block *Block) error {
+	if pod.validators.Len() == 0 {
 		fmt.Println("❌ No validators registered! Block cannot be approved.")
-		return false
+		return errors.New("no validators registered")
+	}
+	if pod.voting == nil {
+		return errors.New("voting is not running on this node")
 	}
 
-	approvedVotes := 0
-	requiredVotes := (len(pod.Validators) * 75) / 100 // ✅ Requires 75% approval
+	if err := pod.voting.Propose(block); err != nil {
+		fmt.Println("❌ Block rejected: no commit from validators.")
+		return err
+	}
+	fmt.Println("✅ Block committed by validators!")
+	return nil
+}
 
-	// Validators approve the block
-	for _, validator := range pod.Validators {
-		if validator.ApproveBlock(block) {
-			approvedVotes++
+// Rewards pays every validator whose precommit is in the parent's commit certificate: a
+// block's own commit only exists once it is sealed, so the next block pays for it
+func (pod *PoDConsensus) Rewards(parent Block, proposer string) []Payout {
+	if parent.Commit == nil || pod.Params.BlockReward <= 0 {
+		return nil
+	}
+	var payouts []Payout
+	for _, validator := range pod.validators.List() {
+		if parent.Commit.HasPrecommit(validator.ID) {
+			payouts = append(payouts, Payout{Address: validator.Address, Amount: int64(pod.Params.BlockReward)}) // Reward each committing validator
 		}
 	}
+	return payouts
+}
 
-	fmt.Printf("🔍 Block #%d Approval: %d/%d validators approved\n", block.Index, approvedVotes, len(pod.Validators))
+// ValidateProposal checks the block's commit certificate: precommits for the block, all from
+// one round, signed by distinct validators active after the parent (state) holding a quorum
+// of the voting power. Before the rules require a certificate, a block may instead carry the
+// approvals PoD blocks were made final with at the time (see validateApprovals).
+func (pod *PoDConsensus) ValidateProposal(state *State, block Block) error {
+	commit := block.Commit
+	if commit == nil {
+		if pod.config.RulesAt(block.Index).CommitCertificate {
+			return errors.New("block has no commit certificate")
+		}
+		return pod.validateApprovals(block)
+	}
+	if len(block.Approvals) > 0 {
+		return errors.New("block carries both approvals and a commit certificate")
+	}
 
-	// ✅ Block is valid if 75% of validators approve
-	if approvedVotes >= requiredVotes {
-		fmt.Println("✅ Block approved by validators!")
+	seen := make(map[string]bool)
+	ids := make([]string, 0, len(commit.Precommits))
+	for _, vote := range commit.Precommits {
+		if vote.Type != VotePrecommit || vote.Height != block.Index || vote.Round != commit.Round || vote.BlockHash != block.Hash {
+			return fmt.Errorf("commit contains a vote that is not a round %d precommit for the block", commit.Round)
+		}
+		if seen[vote.ValidatorID] {
+			return fmt.Errorf("duplicate precommit from validator %s", vote.ValidatorID)
+		}
+		seen[vote.ValidatorID] = true
 
-		// ✅ Reward validators who approved the block
-		for _, validator := range pod.Validators {
-			if validator.ApproveBlock(block) {
-				validator.RewardValidator(10) // Reward each approving validator
-			}
+		validator := pod.validators.ByID(vote.ValidatorID)
+		if validator == nil {
+			return fmt.Errorf("precommit from unknown validator %s", vote.ValidatorID)
 		}
+		if record, _ := state.Validator(validator.ID); record.Jailed {
+			return fmt.Errorf("precommit from jailed validator %s", vote.ValidatorID)
+		}
+		if !validator.VerifyVote(vote) {
+			return fmt.Errorf("invalid precommit signature from validator %s", vote.ValidatorID)
+		}
+		ids = append(ids, vote.ValidatorID)
+	}
 
-		return true
+	if !pod.HasQuorum(state, ids) {
+		return fmt.Errorf("precommits from %d validator(s) do not hold a quorum of the voting power", len(ids))
 	}
+	return nil
+}
+
+// validateApprovals checks the approvals of a block made final before commit certificates:
+// signatures over the block hash by distinct validators, from at least the approval
+// threshold of the validator set
+func (pod *PoDConsensus) validateApprovals(block Block) error {
+	seen := make(map[string]bool)
+	for _, approval := range block.Approvals {
+		if seen[approval.ValidatorID] {
+			return fmt.Errorf("duplicate approval from validator %s", approval.ValidatorID)
+		}
+		seen[approval.ValidatorID] = true
 
-	fmt.Println("❌ Block rejected due to insufficient votes.")
-	return false
+		validator := pod.validators.ByID(approval.ValidatorID)
+		if validator == nil {
+			return fmt.Errorf("approval from unknown validator %s", approval.ValidatorID)
+		}
+		publicKey, err := ParsePublicKey(validator.KeyType, validator.PublicKey)
+		if err != nil || !VerifySignature(publicKey, block.Hash, approval.Signature) {
+			return fmt.Errorf("invalid approval signature from validator %s", approval.ValidatorID)
+		}
+	}
+
+	required := (pod.validators.Len()*pod.Params.ApprovalThreshold + 99) / 100
+	if len(seen) == 0 || len(seen) < required {
+		return fmt.Errorf("only %d of %d required validator approvals", len(seen), required)
+	}
+	return nil
 }
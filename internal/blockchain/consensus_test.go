@@ -0,0 +1,126 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"testing"
+)
+
+// testGenesis returns a PoD genesis with one validator per stake, named v0, v1, ...
+func testGenesis(t *testing.T, stakes []int64) *Genesis {
+	t.Helper()
+	genesis, _ := testGenesisKeys(t, stakes)
+	return genesis
+}
+
+// testGenesisKeys is testGenesis that also returns the validators' keys, in the same order
+func testGenesisKeys(t *testing.T, stakes []int64) (*Genesis, []Signer) {
+	t.Helper()
+	genesis := &Genesis{
+		ChainID:     "pod-test",
+		GenesisTime: "2025-01-01T00:00:00Z",
+		Params:      DefaultChainParams(),
+		Upgrades:    DefaultUpgrades(),
+	}
+	var signers []Signer
+	for i, stake := range stakes {
+		signer, err := GenerateSigner(KeyTypeEd25519)
+		if err != nil {
+			t.Fatal(err)
+		}
+		genesis.Validators = append(genesis.Validators, GenesisValidator{
+			ID:        fmt.Sprintf("v%d", i),
+			KeyType:   signer.Public().KeyType(),
+			PublicKey: EncodePublicKey(signer.Public()),
+			Stake:     stake,
+		})
+		signers = append(signers, signer)
+	}
+	return genesis, signers
+}
+
+// testPrevHash returns a block hash that differs at every height
+func testPrevHash(height int) string {
+	hash := sha256.Sum256([]byte(fmt.Sprint("block", height)))
+	return hex.EncodeToString(hash[:])
+}
+
+func TestSelectProposerFollowsStake(t *testing.T) {
+	const heights = 10000
+
+	tests := []struct {
+		name      string
+		stakes    []int64
+		jailed    []string
+		tolerance float64 // Largest accepted difference between a share and its expected share
+	}{
+		{name: "equal stakes", stakes: []int64{100, 100, 100, 100}, tolerance: 0.02},
+		{name: "weighted stakes", stakes: []int64{10, 20, 30, 40}, tolerance: 0.02},
+		{name: "dominant validator", stakes: []int64{900, 50, 50}, tolerance: 0.02},
+		{name: "no stake weighs equally", stakes: []int64{0, 0, 0}, tolerance: 0.02},
+		{name: "zero stake next to stake", stakes: []int64{0, 60, 40}, tolerance: 0.02},
+		{name: "jailed validator", stakes: []int64{50, 25, 25}, jailed: []string{"v0"}, tolerance: 0.02},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			genesis := testGenesis(t, tt.stakes)
+			pod := NewPoDConsensusFromGenesis(genesis)
+			state := NewState(genesis)
+			for _, id := range tt.jailed {
+				state.validators[id].Jailed = true
+			}
+
+			// ✅ Expected shares: voting power over the active validators' total, or equal without stake
+			expected := make(map[string]float64)
+			var total int64
+			active := pod.Validators().Active(state)
+			for _, v := range active {
+				total += state.VotingPower(v.ID)
+			}
+			for _, v := range active {
+				if total == 0 {
+					expected[v.ID] = 1 / float64(len(active))
+				} else {
+					expected[v.ID] = float64(state.VotingPower(v.ID)) / float64(total)
+				}
+			}
+
+			picks := make(map[string]int)
+			for height := 0; height < heights; height++ {
+				state.height = height
+				proposer := pod.SelectProposer(state, testPrevHash(height), 0)
+				if proposer == nil {
+					t.Fatalf("no proposer at height %d", height+1)
+				}
+				picks[proposer.ID]++
+			}
+
+			for _, v := range pod.Validators().List() {
+				share := float64(picks[v.ID]) / heights
+				if diff := math.Abs(share - expected[v.ID]); diff > tt.tolerance {
+					t.Errorf("validator %s proposed %.3f of the blocks, expected %.3f", v.ID, share, expected[v.ID])
+				}
+			}
+		})
+	}
+}
+
+func TestSelectProposerRotatesRounds(t *testing.T) {
+	genesis := testGenesis(t, []int64{10, 20, 30, 40})
+	pod := NewPoDConsensusFromGenesis(genesis)
+	state := NewState(genesis)
+
+	for height := 0; height < 100; height++ {
+		state.height = height
+		seen := make(map[string]bool)
+		for round := 0; round < len(genesis.Validators); round++ {
+			seen[pod.SelectProposer(state, testPrevHash(height), round).ID] = true
+		}
+		if len(seen) != len(genesis.Validators) {
+			t.Fatalf("height %d: %d of %d validators propose in %d rounds", height+1, len(seen), len(genesis.Validators), len(genesis.Validators))
+		}
+	}
+}
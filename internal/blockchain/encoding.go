@@ -0,0 +1,819 @@
+package blockchain
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Canonical encoding (documented in docs/encoding.md)
+//
+// Every value has exactly one encoding so hashes and signatures can be recomputed
+// byte-for-byte by clients in any language:
+//   - integers are fixed-width big-endian (int64 as two's complement)
+//   - float64 is its IEEE-754 bit pattern as a big-endian uint64
+//   - strings are a uint32 byte length followed by the raw UTF-8 bytes
+//   - lists are a uint32 element count followed by the elements
+//
+// Each top-level encoding starts with a one-byte tag and the encoding version.
+const EncodingVersion byte = 1
+
+// Tags of the top-level encodings
+const (
+	tagTransaction byte = 'T' // Transaction contents hashed into the TxID
+	tagEvidence    byte = 'E' // Evidence transaction contents hashed into the TxID
+	tagTransfer    byte = 'X' // Transfer (signed without the signature; hashed into the TxID with it)
+	tagStake       byte = 'S' // Stake (signed without the signature; hashed into the TxID with it)
+	tagUnstake     byte = 'U' // Unstake (signed without the signature; hashed into the TxID with it)
+	tagMetadata    byte = 'F' // File metadata update (signed without the signature; hashed into the TxID with it)
+	tagRevocation  byte = 'K' // File revocation (signed without the signature; hashed into the TxID with it)
+	tagCoinbase    byte = 'M' // Coinbase contents hashed into the TxID
+	tagBlockHeader byte = 'H' // Block header fields hashed into the block hash (versioned per header)
+	tagBlock       byte = 'B' // Complete block as sent over the wire
+	tagChain       byte = 'C' // Length of a chain sent over the wire, block by block
+	tagGenesis     byte = 'G' // Genesis file contents hashed into the genesis block
+	tagState       byte = 'Z' // Chain state hashed into the state root
+	tagHello       byte = 'V' // Handshake opening every peer connection
+	tagProposal    byte = 'P' // Block proposed for a voting round
+	tagVote        byte = 'R' // Prevote or precommit of a voting round (signed without the signature)
+)
+
+// maxEncodedLength bounds decoded string and list lengths so corrupt input cannot exhaust memory
+const maxEncodedLength = 64 << 20
+
+// ErrMalformedEncoding is returned for input that is not a valid canonical encoding
+var ErrMalformedEncoding = errors.New("malformed canonical encoding")
+
+// ============================
+// Encoder / decoder primitives
+// ============================
+
+type encoder struct {
+	buf []byte
+}
+
+func newEncoder(tag byte) *encoder {
+	return &encoder{buf: []byte{tag, EncodingVersion}}
+}
+
+func (e *encoder) uint8(v byte) {
+	e.buf = append(e.buf, v)
+}
+
+func (e *encoder) uint32(v uint32) {
+	e.buf = binary.BigEndian.AppendUint32(e.buf, v)
+}
+
+func (e *encoder) uint64(v uint64) {
+	e.buf = binary.BigEndian.AppendUint64(e.buf, v)
+}
+
+func (e *encoder) int64(v int64) {
+	e.uint64(uint64(v))
+}
+
+func (e *encoder) float64(v float64) {
+	e.uint64(math.Float64bits(v))
+}
+
+func (e *encoder) string(v string) {
+	e.uint32(uint32(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+func (e *encoder) strings(values []string) {
+	e.uint32(uint32(len(values)))
+	for _, v := range values {
+		e.string(v)
+	}
+}
+
+// decoder reads primitives in order; the first error sticks and later reads return zero values
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func newDecoder(data []byte, tag byte) *decoder {
+	d := &decoder{data: data}
+	if gotTag := d.uint8(); d.err == nil && gotTag != tag {
+		d.fail("unexpected tag %q, want %q", gotTag, tag)
+	}
+	if version := d.uint8(); d.err == nil && version != EncodingVersion {
+		d.fail("unsupported encoding version %d", version)
+	}
+	return d
+}
+
+func (d *decoder) fail(format string, args ...interface{}) {
+	if d.err == nil {
+		d.err = fmt.Errorf("%w: %s", ErrMalformedEncoding, fmt.Sprintf(format, args...))
+	}
+}
+
+func (d *decoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n > len(d.data) {
+		d.fail("need %d bytes, have %d", n, len(d.data))
+		return nil
+	}
+	out := d.data[:n]
+	d.data = d.data[n:]
+	return out
+}
+
+func (d *decoder) uint8() byte {
+	if b := d.take(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) uint32() uint32 {
+	if b := d.take(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *decoder) uint64() uint64 {
+	if b := d.take(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (d *decoder) int64() int64 {
+	return int64(d.uint64())
+}
+
+func (d *decoder) float64() float64 {
+	return math.Float64frombits(d.uint64())
+}
+
+// length reads a string or list length and checks it against the remaining input
+func (d *decoder) length() int {
+	n := d.uint32()
+	if d.err == nil && (n > maxEncodedLength || int(n) > len(d.data)) {
+		d.fail("length %d exceeds input", n)
+		return 0
+	}
+	return int(n)
+}
+
+func (d *decoder) string() string {
+	return string(d.take(d.length()))
+}
+
+func (d *decoder) strings() []string {
+	n := d.length()
+	if n == 0 {
+		return nil
+	}
+	values := make([]string, 0, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		values = append(values, d.string())
+	}
+	return values
+}
+
+// finish reports the first error, or trailing bytes after a complete value
+func (d *decoder) finish() error {
+	if d.err == nil && len(d.data) > 0 {
+		d.fail("%d trailing bytes", len(d.data))
+	}
+	return d.err
+}
+
+// ============================
+// Transactions
+// ============================
+
+// CanonicalBytes returns the canonical encoding of the transaction contents that
+// TxID commits to: the tag of its type, its payload and its fee, if it pays one. Approvals
+// and TxID itself are not part of it.
+func (tx *Transaction) CanonicalBytes() []byte {
+	e := newEncoder(txTypes[tx.Type].tag)
+	encodePayload(e, *tx)
+	encodeFee(e, tx.Fee)
+	return e.buf
+}
+
+// SignBytes returns what the sender of an account transaction signs: its canonical encoding
+// without the signature. It is nil for other types.
+func (tx *Transaction) SignBytes() []byte {
+	if tx.Sender() == nil {
+		return nil
+	}
+	e := newEncoder(txTypes[tx.Type].tag)
+	encodeAccountBody(e, *tx)
+	encodeFee(e, tx.Fee)
+	return e.buf
+}
+
+// encodeFee writes the fee of a transaction that pays one; transactions without a fee keep
+// the encoding (and TxID) they had before fees existed
+func encodeFee(e *encoder, fee int64) {
+	if fee != 0 {
+		e.int64(fee)
+	}
+}
+
+// encodePayload writes the payload of a transaction; a transaction without the payload of
+// its type has none
+func encodePayload(e *encoder, tx Transaction) {
+	if t, ok := txTypes[tx.Type]; !ok || !t.payload(&tx) {
+		return
+	}
+	switch tx.Type {
+	case TxFile:
+		encodeFileBody(e, *tx.File)
+	case TxEvidence:
+		encodeEvidence(e, *tx.Evidence)
+	case TxCoinbase:
+		encodeCoinbase(e, *tx.Coinbase)
+	default:
+		encodeAccountBody(e, tx)
+		e.string(tx.Sender().Signature)
+	}
+}
+
+func decodePayload(d *decoder, txType TxType) Transaction {
+	tx := Transaction{Type: txType}
+	switch txType {
+	case TxFile:
+		file := decodeFileBody(d)
+		tx.File = &file
+	case TxEvidence:
+		evidence := decodeEvidence(d)
+		tx.Evidence = &evidence
+	case TxCoinbase:
+		coinbase := decodeCoinbase(d)
+		tx.Coinbase = &coinbase
+	default:
+		decodeAccountBody(d, &tx)
+		tx.Sender().Signature = d.string()
+	}
+	return tx
+}
+
+func encodeFileBody(e *encoder, file FileNotarization) {
+	e.string(file.FileHash)
+	e.string(file.Uploader)
+	e.string(string(file.KeyType))
+	e.string(file.PublicKey)
+	e.int64(file.Size)
+	e.float64(file.TrustScore)
+	e.uint64(file.Nonce)
+	e.string(file.Signature)
+}
+
+func decodeFileBody(d *decoder) FileNotarization {
+	return FileNotarization{
+		FileHash:   d.string(),
+		Uploader:   d.string(),
+		KeyType:    KeyType(d.string()),
+		PublicKey:  d.string(),
+		Size:       d.int64(),
+		TrustScore: d.float64(),
+		Nonce:      d.uint64(),
+		Signature:  d.string(),
+	}
+}
+
+// encodeAccountBody writes an account transaction without its signature: the sender, the
+// fields of its type, then the sender's nonce and key
+func encodeAccountBody(e *encoder, tx Transaction) {
+	sender := tx.Sender()
+	e.string(sender.From)
+	switch tx.Type {
+	case TxTransfer:
+		e.string(tx.Transfer.To)
+		e.int64(tx.Transfer.Amount)
+	case TxStake, TxUnstake:
+		e.int64(tx.Stake.Amount)
+	case TxMetadata:
+		e.string(tx.Metadata.FileHash)
+		keys := sortedKeys(tx.Metadata.Fields)
+		e.uint32(uint32(len(keys)))
+		for _, key := range keys {
+			e.string(key)
+			e.string(tx.Metadata.Fields[key])
+		}
+	case TxRevocation:
+		e.string(tx.Revocation.FileHash)
+		e.string(tx.Revocation.Reason)
+	}
+	e.uint64(sender.Nonce)
+	e.string(string(sender.KeyType))
+	e.string(sender.PublicKey)
+}
+
+func decodeAccountBody(d *decoder, tx *Transaction) {
+	from := d.string()
+	switch tx.Type {
+	case TxTransfer:
+		tx.Transfer = &Transfer{To: d.string(), Amount: d.int64()}
+	case TxStake, TxUnstake:
+		tx.Stake = &StakeChange{Amount: d.int64()}
+	case TxMetadata:
+		tx.Metadata = &MetadataUpdate{FileHash: d.string(), Fields: make(map[string]string)}
+		for i, n := 0, d.length(); i < n; i++ {
+			key := d.string()
+			tx.Metadata.Fields[key] = d.string()
+		}
+	case TxRevocation:
+		tx.Revocation = &Revocation{FileHash: d.string(), Reason: d.string()}
+	}
+	sender := tx.Sender()
+	sender.From = from
+	sender.Nonce = d.uint64()
+	sender.KeyType = KeyType(d.string())
+	sender.PublicKey = d.string()
+}
+
+// wireFeeFlag is set in the wire kind of a transaction that pays a fee; the fee follows the kind
+const wireFeeFlag byte = 0x80
+
+// encodeTransaction writes a complete transaction for the wire: its ID, the wire kind of its
+// type, its fee if it pays one, its payload and, for files, the validator approvals
+func encodeTransaction(e *encoder, tx Transaction) {
+	e.string(tx.TxID)
+	if tx.Fee != 0 {
+		e.uint8(txTypes[tx.Type].kind | wireFeeFlag)
+		e.int64(tx.Fee)
+	} else {
+		e.uint8(txTypes[tx.Type].kind)
+	}
+	encodePayload(e, tx)
+	if tx.Type == TxFile {
+		encodeApprovals(e, tx.Approvals)
+	}
+}
+
+// Size returns the size of the transaction's wire encoding, which counts against the block
+// size limit and prices its fee (see FeePerByte)
+func (tx *Transaction) Size() int {
+	e := &encoder{}
+	encodeTransaction(e, *tx)
+	return len(e.buf)
+}
+
+func decodeTransaction(d *decoder) Transaction {
+	txID := d.string()
+	kind := d.uint8()
+	var fee int64
+	if kind&wireFeeFlag != 0 {
+		kind &^= wireFeeFlag
+		if fee = d.int64(); d.err == nil && fee == 0 {
+			d.fail("zero fee sent with the fee flag")
+		}
+	}
+	txType, ok := txTypeOfKind(kind)
+	if !ok {
+		d.fail("unknown transaction kind %d", kind)
+		return Transaction{}
+	}
+	tx := decodePayload(d, txType)
+	tx.TxID = txID
+	tx.Fee = fee
+	if txType == TxFile {
+		tx.Approvals = decodeApprovals(d)
+	}
+	return tx
+}
+
+func encodeEvidence(e *encoder, evidence DuplicateVoteEvidence) {
+	for _, vote := range []Vote{evidence.VoteA, evidence.VoteB} {
+		encodeVoteBody(e, vote)
+		e.string(vote.Signature)
+	}
+}
+
+func decodeEvidence(d *decoder) DuplicateVoteEvidence {
+	var votes [2]Vote
+	for i := range votes {
+		votes[i] = Vote{
+			Type:        VoteType(d.uint8()),
+			Height:      int(d.int64()),
+			Round:       int(d.int64()),
+			BlockHash:   d.string(),
+			ValidatorID: d.string(),
+			Signature:   d.string(),
+		}
+	}
+	return DuplicateVoteEvidence{VoteA: votes[0], VoteB: votes[1]}
+}
+
+func encodeCoinbase(e *encoder, coinbase Coinbase) {
+	e.int64(int64(coinbase.Height))
+	e.uint32(uint32(len(coinbase.Payouts)))
+	for _, payout := range coinbase.Payouts {
+		e.string(payout.Address)
+		e.int64(payout.Amount)
+	}
+}
+
+func decodeCoinbase(d *decoder) Coinbase {
+	coinbase := Coinbase{Height: int(d.int64())}
+	for i, n := 0, d.length(); i < n; i++ {
+		coinbase.Payouts = append(coinbase.Payouts, Payout{Address: d.string(), Amount: d.int64()})
+	}
+	return coinbase
+}
+
+// ============================
+// Blocks
+// ============================
+
+// Bytes returns the canonical encoding of the header that the block hash commits to.
+// The version byte of the encoding is the header version, and each version has its own layout.
+func (h BlockHeader) Bytes() ([]byte, error) {
+	e := &encoder{buf: []byte{tagBlockHeader}}
+	switch h.Version {
+	case BlockVersion1:
+		e.uint8(byte(BlockVersion1))
+		e.int64(int64(h.Index))
+		e.string(h.Timestamp)
+		e.string(h.PreviousHash)
+		e.string(h.MerkleRoot)
+	case BlockVersion2, BlockVersion3:
+		e.uint8(byte(h.Version))
+		e.string(h.ChainID)
+		e.int64(int64(h.Index))
+		e.string(h.Timestamp)
+		e.string(h.PreviousHash)
+		e.string(h.MerkleRoot)
+		e.string(h.Proposer)
+	case BlockVersion4, BlockVersion5, BlockVersion6, BlockVersion7:
+		e.uint8(byte(h.Version))
+		e.string(h.ChainID)
+		e.int64(int64(h.Index))
+		e.string(h.Timestamp)
+		e.string(h.PreviousHash)
+		e.string(h.MerkleRoot)
+		e.string(h.Proposer)
+		e.string(h.StateRoot)
+	default:
+		return nil, fmt.Errorf("unknown block version %d", h.Version)
+	}
+	return e.buf, nil
+}
+
+// EncodeBlock returns the canonical encoding of a complete block, as sent to peers
+func EncodeBlock(block Block) []byte {
+	e := newEncoder(tagBlock)
+	encodeBlock(e, block)
+	return e.buf
+}
+
+// DecodeBlock parses a block produced by EncodeBlock
+func DecodeBlock(data []byte) (Block, error) {
+	d := newDecoder(data, tagBlock)
+	block := decodeBlock(d)
+	return block, d.finish()
+}
+
+// EncodeChainHeader returns the message announcing a chain sent to peers: its length. Each of
+// its blocks follows in a message of its own (see EncodeBlock), so no message is larger than
+// a block.
+func EncodeChainHeader(length int) []byte {
+	e := newEncoder(tagChain)
+	e.uint32(uint32(length))
+	return e.buf
+}
+
+// DecodeChainHeader parses a message produced by EncodeChainHeader
+func DecodeChainHeader(data []byte) (int, error) {
+	d := newDecoder(data, tagChain)
+	length := int(d.uint32())
+	return length, d.finish()
+}
+
+func encodeBlock(e *encoder, block Block) {
+	e.uint32(block.Version)
+	e.string(block.ChainID)
+	e.int64(int64(block.Index))
+	e.string(block.Timestamp)
+	e.string(block.PreviousHash)
+	e.string(block.MerkleRoot)
+	e.string(block.Proposer)
+	if block.Version >= BlockVersion4 {
+		e.string(block.StateRoot)
+	}
+	e.string(block.Hash)
+	e.string(string(block.KeyType))
+	e.string(block.PublicKey)
+	e.string(block.Signature)
+
+	e.uint32(uint32(len(block.Transactions)))
+	for _, tx := range block.Transactions {
+		encodeTransaction(e, tx)
+	}
+
+	// ✅ Precommits share the block's height and hash, so only their round, validator and signature are sent
+	if block.Commit == nil {
+		if len(block.Approvals) > 0 {
+			e.uint8(2)
+			encodeApprovals(e, block.Approvals)
+			return
+		}
+		e.uint8(0)
+		return
+	}
+	e.uint8(1)
+	e.int64(int64(block.Commit.Round))
+	e.uint32(uint32(len(block.Commit.Precommits)))
+	for _, vote := range block.Commit.Precommits {
+		e.string(vote.ValidatorID)
+		e.string(vote.Signature)
+	}
+}
+
+func decodeBlock(d *decoder) Block {
+	block := Block{
+		BlockHeader: BlockHeader{
+			Version:      d.uint32(),
+			ChainID:      d.string(),
+			Index:        int(d.int64()),
+			Timestamp:    d.string(),
+			PreviousHash: d.string(),
+			MerkleRoot:   d.string(),
+			Proposer:     d.string(),
+		},
+	}
+	if block.Version >= BlockVersion4 {
+		block.StateRoot = d.string()
+	}
+	block.Hash = d.string()
+	block.KeyType = KeyType(d.string())
+	block.PublicKey = d.string()
+	block.Signature = d.string()
+
+	if n := d.length(); n > 0 {
+		block.Transactions = make([]Transaction, 0, n)
+		for i := 0; i < n && d.err == nil; i++ {
+			block.Transactions = append(block.Transactions, decodeTransaction(d))
+		}
+	}
+
+	switch d.uint8() {
+	case 0:
+	case 1:
+		commit := &CommitCertificate{Round: int(d.int64())}
+		n := d.length()
+		commit.Precommits = make([]Vote, 0, n)
+		for i := 0; i < n && d.err == nil; i++ {
+			commit.Precommits = append(commit.Precommits, Vote{
+				Type:        VotePrecommit,
+				Height:      block.Index,
+				Round:       commit.Round,
+				BlockHash:   block.Hash,
+				ValidatorID: d.string(),
+				Signature:   d.string(),
+			})
+		}
+		block.Commit = commit
+	case 2:
+		block.Approvals = decodeApprovals(d)
+	default:
+		d.fail("invalid commit marker")
+	}
+	return block
+}
+
+// encodeApprovals writes a list of validator approvals: ID, then signature
+func encodeApprovals(e *encoder, approvals []Approval) {
+	e.uint32(uint32(len(approvals)))
+	for _, approval := range approvals {
+		e.string(approval.ValidatorID)
+		e.string(approval.Signature)
+	}
+}
+
+func decodeApprovals(d *decoder) []Approval {
+	n := d.length()
+	if n == 0 {
+		return nil
+	}
+	approvals := make([]Approval, 0, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		approvals = append(approvals, Approval{ValidatorID: d.string(), Signature: d.string()})
+	}
+	return approvals
+}
+
+// ============================
+// Voting
+// ============================
+
+// SignBytes returns the canonical encoding of a vote without its signature; validators sign it
+func (v Vote) SignBytes() []byte {
+	e := newEncoder(tagVote)
+	encodeVoteBody(e, v)
+	return e.buf
+}
+
+func encodeVoteBody(e *encoder, v Vote) {
+	e.uint8(byte(v.Type))
+	e.int64(int64(v.Height))
+	e.int64(int64(v.Round))
+	e.string(v.BlockHash)
+	e.string(v.ValidatorID)
+}
+
+// EncodeVote returns the wire encoding of a signed vote
+func EncodeVote(v Vote) []byte {
+	e := newEncoder(tagVote)
+	encodeVoteBody(e, v)
+	e.string(v.Signature)
+	return e.buf
+}
+
+// DecodeVote parses a vote produced by EncodeVote
+func DecodeVote(data []byte) (Vote, error) {
+	d := newDecoder(data, tagVote)
+	vote := Vote{
+		Type:        VoteType(d.uint8()),
+		Height:      int(d.int64()),
+		Round:       int(d.int64()),
+		BlockHash:   d.string(),
+		ValidatorID: d.string(),
+		Signature:   d.string(),
+	}
+	if vote.Type != VotePrevote && vote.Type != VotePrecommit {
+		d.fail("unknown vote type %d", vote.Type)
+	}
+	return vote, d.finish()
+}
+
+// EncodeProposal returns the wire encoding of a block proposed in a voting round
+func EncodeProposal(round int, block Block) []byte {
+	e := newEncoder(tagProposal)
+	e.int64(int64(round))
+	encodeBlock(e, block)
+	return e.buf
+}
+
+// DecodeProposal parses a proposal produced by EncodeProposal
+func DecodeProposal(data []byte) (int, Block, error) {
+	d := newDecoder(data, tagProposal)
+	round := int(d.int64())
+	block := decodeBlock(d)
+	return round, block, d.finish()
+}
+
+// ============================
+// State
+// ============================
+
+// Bytes returns the canonical encoding of the state that its root commits to: non-empty
+// accounts by address, validators by ID, files by hash and, once any is used, upload nonces
+// by uploader
+func (s *State) Bytes() []byte {
+	e := newEncoder(tagState)
+
+	addresses := make([]string, 0, len(s.ledger.accounts))
+	for address, account := range s.ledger.accounts {
+		if account.Balance != 0 || account.Bonded != 0 || account.Nonce != 0 {
+			addresses = append(addresses, address)
+		}
+	}
+	sort.Strings(addresses)
+	e.uint32(uint32(len(addresses)))
+	for _, address := range addresses {
+		account := s.ledger.accounts[address]
+		e.string(account.Address)
+		e.int64(account.Balance)
+		e.int64(account.Bonded)
+		e.uint64(account.Nonce)
+	}
+
+	ids := make([]string, 0, len(s.validators))
+	for id := range s.validators {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	e.uint32(uint32(len(ids)))
+	for _, id := range ids {
+		v := s.validators[id]
+		e.string(v.ID)
+		e.string(v.Address)
+		e.int64(v.Stake)
+		e.uint8(encodeBool(v.Jailed))
+		e.int64(int64(v.JailedAt))
+	}
+
+	hashes := make([]string, 0, len(s.files))
+	for hash := range s.files {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	e.uint32(uint32(len(hashes)))
+	for _, hash := range hashes {
+		record := s.files[hash]
+		e.string(record.FileHash)
+		e.string(record.Uploader)
+		e.int64(int64(record.Height))
+		e.string(record.TxID)
+		keys := sortedKeys(record.Metadata)
+		e.uint32(uint32(len(keys)))
+		for _, key := range keys {
+			e.string(key)
+			e.string(record.Metadata[key])
+		}
+		e.uint8(encodeBool(record.Revoked))
+		e.int64(int64(record.RevokedAt))
+		e.string(record.RevocationReason)
+	}
+
+	// ✅ States from before block version 6 record no upload nonces and keep their root
+	if len(s.uploads) == 0 {
+		return e.buf
+	}
+	uploaders := make([]string, 0, len(s.uploads))
+	for uploader := range s.uploads {
+		uploaders = append(uploaders, uploader)
+	}
+	sort.Strings(uploaders)
+	e.uint32(uint32(len(uploaders)))
+	for _, uploader := range uploaders {
+		nonces := make([]uint64, 0, len(s.uploads[uploader]))
+		for nonce := range s.uploads[uploader] {
+			nonces = append(nonces, nonce)
+		}
+		sort.Slice(nonces, func(i, j int) bool { return nonces[i] < nonces[j] })
+		e.string(uploader)
+		e.uint32(uint32(len(nonces)))
+		for _, nonce := range nonces {
+			e.uint64(nonce)
+		}
+	}
+	return e.buf
+}
+
+// encodeBool encodes a flag as 1 or 0
+func encodeBool(v bool) byte {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+// ============================
+// Genesis and handshake
+// ============================
+
+// CanonicalBytes returns the canonical encoding of the genesis file
+func (g *Genesis) CanonicalBytes() []byte {
+	e := newEncoder(tagGenesis)
+	e.string(g.ChainID)
+	e.string(g.GenesisTime)
+
+	e.uint32(uint32(len(g.Validators)))
+	for _, v := range g.Validators {
+		e.string(v.ID)
+		e.string(string(v.KeyType))
+		e.string(v.PublicKey)
+		e.int64(v.Stake)
+	}
+
+	e.uint32(uint32(len(g.Allocations)))
+	for _, allocation := range g.Allocations {
+		e.string(allocation.Address)
+		e.int64(allocation.Amount)
+	}
+
+	e.string(g.Params.Consensus)
+	e.int64(int64(g.Params.ApprovalThreshold))
+	e.int64(int64(g.Params.BlockReward))
+	e.int64(int64(g.Params.MaxBlockBytes))
+	e.int64(int64(g.Params.SlashPercent))
+
+	e.uint32(uint32(len(g.Upgrades)))
+	for _, upgrade := range g.Upgrades {
+		e.string(upgrade.Name)
+		e.int64(int64(upgrade.Height))
+		e.uint32(upgrade.BlockVersion)
+	}
+	return e.buf
+}
+
+// encodeHello returns the handshake message naming the sender's chain and genesis block
+func encodeHello(chainID string, genesisHash string) []byte {
+	e := newEncoder(tagHello)
+	e.string(chainID)
+	e.string(genesisHash)
+	return e.buf
+}
+
+func decodeHello(data []byte) (chainID string, genesisHash string, err error) {
+	d := newDecoder(data, tagHello)
+	chainID = d.string()
+	genesisHash = d.string()
+	return chainID, genesisHash, d.finish()
+}
@@ -0,0 +1,238 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// vectorLine starts a golden vector: "Name = value"
+var vectorLine = regexp.MustCompile(`^(\w+)\s+=\s+(.*)$`)
+
+// goldenVectors parses the "Golden vectors" section of docs/encoding.md. Values spread over
+// indented lines are joined without spaces.
+func goldenVectors(t *testing.T) map[string]string {
+	t.Helper()
+	doc, err := os.ReadFile("../../docs/encoding.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, section, ok := strings.Cut(string(doc), "## Golden vectors")
+	if !ok {
+		t.Fatal("docs/encoding.md has no golden vectors section")
+	}
+
+	vectors := make(map[string]string)
+	name, inBlock := "", false
+	for _, line := range strings.Split(section, "\n") {
+		switch {
+		case strings.HasPrefix(line, "```"):
+			inBlock, name = !inBlock, ""
+		case !inBlock || strings.TrimSpace(line) == "":
+			name = ""
+		case vectorLine.MatchString(line):
+			m := vectorLine.FindStringSubmatch(line)
+			if _, dup := vectors[m[1]]; dup {
+				t.Fatalf("golden vector %s is defined twice", m[1])
+			}
+			name = m[1]
+			vectors[name] = strings.ReplaceAll(m[2], " ", "")
+		case name != "" && strings.HasPrefix(line, " "):
+			vectors[name] += strings.ReplaceAll(line, " ", "")
+		default:
+			t.Fatalf("unexpected line in golden vectors: %q", line)
+		}
+	}
+	return vectors
+}
+
+// vectorBytes returns a hex golden vector
+func vectorBytes(t *testing.T, vectors map[string]string, name string) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(vectors[name])
+	if err != nil || len(data) == 0 {
+		t.Fatalf("golden vector %s is not hex: %q", name, vectors[name])
+	}
+	return data
+}
+
+// vectorInt returns a decimal golden vector
+func vectorInt(t *testing.T, vectors map[string]string, name string) int64 {
+	t.Helper()
+	n, err := strconv.ParseInt(vectors[name], 10, 64)
+	if err != nil {
+		t.Fatalf("golden vector %s is not a number: %q", name, vectors[name])
+	}
+	return n
+}
+
+// decodeUpload decodes the canonical encoding of a file transaction, with a trailing fee if
+// withFee is set
+func decodeUpload(t *testing.T, data []byte, withFee bool) Transaction {
+	t.Helper()
+	d := newDecoder(data, tagTransaction)
+	file := decodeFileBody(d)
+	tx := Transaction{Type: TxFile, File: &file}
+	if withFee {
+		tx.Fee = d.int64()
+	}
+	if err := d.finish(); err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+func TestGoldenTransactions(t *testing.T) {
+	vectors := goldenVectors(t)
+
+	tests := []struct {
+		encoding, txID, signature string
+		fee                       int64
+	}{
+		{encoding: "T", txID: "TxID", signature: "Signature"},
+		{encoding: "TFee", txID: "TxIDFee", signature: "FeeSignature", fee: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.encoding, func(t *testing.T) {
+			encoded := vectorBytes(t, vectors, tt.encoding)
+			tx := decodeUpload(t, encoded, tt.fee != 0)
+
+			want := FileNotarization{
+				FileHash:   vectors["FileHash"],
+				Uploader:   vectors["Uploader"],
+				KeyType:    KeyType(vectors["KeyType"]),
+				PublicKey:  vectors["PublicKey"],
+				Size:       vectorInt(t, vectors, "Size"),
+				TrustScore: float64(vectorInt(t, vectors, "TrustScore")),
+				Nonce:      uint64(vectorInt(t, vectors, "Nonce")),
+				Signature:  vectors[tt.signature],
+			}
+			if !reflect.DeepEqual(*tx.File, want) {
+				t.Fatalf("decoded %+v, expected %+v", *tx.File, want)
+			}
+			if tx.Fee != tt.fee {
+				t.Fatalf("decoded fee %d, expected %d", tx.Fee, tt.fee)
+			}
+			if !tx.File.VerifySignature(tx.Fee) {
+				t.Fatal("upload signature does not verify")
+			}
+
+			if got := tx.CanonicalBytes(); !bytes.Equal(got, encoded) {
+				t.Fatalf("encoded %x, expected %x", got, encoded)
+			}
+			if got := tx.calculateTxID(); got != vectors[tt.txID] {
+				t.Fatalf("TxID %s, expected %s", got, vectors[tt.txID])
+			}
+		})
+	}
+}
+
+func TestGoldenHeaders(t *testing.T) {
+	vectors := goldenVectors(t)
+	upload := decodeUpload(t, vectorBytes(t, vectors, "T"), false)
+	upload.TxID = upload.calculateTxID()
+	feeUpload := decodeUpload(t, vectorBytes(t, vectors, "TFee"), true)
+	feeUpload.TxID = feeUpload.calculateTxID()
+
+	const timestamp = "2025-01-01T00:00:00Z"
+	tests := []struct {
+		name, merkleRoot, encoding, hash string
+		header                           BlockHeader
+		transactions                     []Transaction
+	}{
+		{
+			name: "genesis", merkleRoot: "GenesisMerkleRoot", hash: "GenesisHash",
+			header: BlockHeader{Version: BlockVersion1, Index: 0, Timestamp: timestamp, PreviousHash: "0"},
+		},
+		{
+			name: "version 1", merkleRoot: "MerkleRoot", encoding: "H1", hash: "Hash1",
+			header:       BlockHeader{Version: BlockVersion1, Index: 1, Timestamp: timestamp, PreviousHash: "0"},
+			transactions: []Transaction{upload},
+		},
+		{
+			name: "version 2", merkleRoot: "MerkleRoot", encoding: "H2", hash: "Hash2",
+			header: BlockHeader{Version: BlockVersion2, ChainID: "pod-devnet", Index: 1, Timestamp: timestamp,
+				PreviousHash: "0", Proposer: vectors["Uploader"]},
+			transactions: []Transaction{upload},
+		},
+		{
+			name: "version 5", merkleRoot: "MerkleRoot5", encoding: "H5", hash: "Hash5",
+			header: BlockHeader{Version: BlockVersion5, ChainID: "pod-devnet", Index: 1, Timestamp: timestamp,
+				PreviousHash: "0", Proposer: vectors["Uploader"], StateRoot: vectors["StateRoot"]},
+			transactions: []Transaction{feeUpload},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := tt.header
+			header.MerkleRoot = MerkleRoot(tt.transactions)
+			if header.MerkleRoot != vectors[tt.merkleRoot] {
+				t.Fatalf("Merkle root %s, expected %s", header.MerkleRoot, vectors[tt.merkleRoot])
+			}
+
+			if tt.encoding != "" {
+				encoded, err := header.Bytes()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := vectorBytes(t, vectors, tt.encoding); !bytes.Equal(encoded, want) {
+					t.Fatalf("encoded %x, expected %x", encoded, want)
+				}
+			}
+
+			hash, err := header.ComputeHash()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hash != vectors[tt.hash] {
+				t.Fatalf("hash %s, expected %s", hash, vectors[tt.hash])
+			}
+		})
+	}
+}
+
+func TestGoldenBlock(t *testing.T) {
+	vectors := goldenVectors(t)
+	encoded := vectorBytes(t, vectors, "B")
+
+	block, err := DecodeBlock(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := EncodeBlock(block); !bytes.Equal(got, encoded) {
+		t.Fatalf("re-encoded %x, expected %x", got, encoded)
+	}
+
+	if block.Version != BlockVersion5 || block.StateRoot != vectors["StateRoot"] || block.Proposer != vectors["Uploader"] {
+		t.Fatalf("decoded header %+v", block.BlockHeader)
+	}
+	if block.MerkleRoot != vectors["MerkleRoot5"] || MerkleRoot(block.Transactions) != block.MerkleRoot {
+		t.Fatalf("Merkle root %s does not match the transactions", block.MerkleRoot)
+	}
+	if hash, err := block.ComputeHash(); err != nil || hash != vectors["Hash5"] || block.Hash != hash {
+		t.Fatalf("hash %s (computed %s, %v), expected %s", block.Hash, hash, err, vectors["Hash5"])
+	}
+	if block.Signature != vectors["BlockSignature"] {
+		t.Fatalf("signature %s, expected %s", block.Signature, vectors["BlockSignature"])
+	}
+	publicKey, err := ParsePublicKey(block.KeyType, block.PublicKey)
+	if err != nil || !VerifySignature(publicKey, block.Hash, block.Signature) {
+		t.Fatalf("block signature does not verify (%v)", err)
+	}
+
+	if len(block.Transactions) != 1 {
+		t.Fatalf("%d transactions, expected 1", len(block.Transactions))
+	}
+	tx := block.Transactions[0]
+	if tx.TxID != vectors["TxIDFee"] || tx.Fee != 5 {
+		t.Fatalf("transaction %s with fee %d, expected %s with fee 5", tx.TxID, tx.Fee, vectors["TxIDFee"])
+	}
+	if got, want := tx.CanonicalBytes(), vectorBytes(t, vectors, "TFee"); !bytes.Equal(got, want) {
+		t.Fatalf("transaction encoded %x, expected %x", got, want)
+	}
+}
@@ -0,0 +1,143 @@
+package blockchain
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Consensus engine names, selected by the "consensus" genesis parameter
+const (
+	EnginePoD = "pod" // Proof-of-Data: stake-weighted proposer, BFT voting across nodes (production)
+	EnginePoA = "poa" // Proof-of-Authority: round-robin proposer, no votes
+	EngineDev = "dev" // Instant seal: fixed proposer, no votes, fully deterministic (tests)
+)
+
+// ConsensusEngine decides who proposes each block, what makes a proposed block final
+// and how block producers are rewarded
+type ConsensusEngine interface {
+	// Name returns the engine name used in the genesis file
+	Name() string
+	// Validators returns the validator set the engine works with
+	Validators() *ValidatorSet
+	// SelectProposer returns the validator that proposes the block after state, the state
+	// after the block with hash prevHash, in a voting round. Over as many consecutive rounds
+	// as there are active validators, each of them proposes once.
+	SelectProposer(state *State, prevHash string, round int) *Validator
+	// Finalize completes a block proposed by this node (e.g. runs the voting); a block
+	// that cannot be finalized must not be added to the chain
+	Finalize(block *Block) error
+	// ValidateProposal checks the consensus data (e.g. the commit certificate) of a block from
+	// any node against state, the state after its parent
+	ValidateProposal(state *State, block Block) error
+	// Rewards returns what the coinbase of the block proposed by proposer (an address) on top
+	// of parent mints to the block producers
+	Rewards(parent Block, proposer string) []Payout
+}
+
+// consensusEngines maps engine names to their constructors
+var consensusEngines = map[string]func(genesis *Genesis) ConsensusEngine{
+	EnginePoD: func(genesis *Genesis) ConsensusEngine { return NewPoDConsensusFromGenesis(genesis) },
+	EnginePoA: func(genesis *Genesis) ConsensusEngine { return NewPoAConsensus(genesis) },
+	EngineDev: func(genesis *Genesis) ConsensusEngine { return NewDevConsensus(genesis) },
+}
+
+// NewConsensusEngine creates the engine named in the genesis parameters (PoD when unset)
+func NewConsensusEngine(genesis *Genesis) (ConsensusEngine, error) {
+	name := genesis.Params.Consensus
+	if name == "" {
+		name = EnginePoD
+	}
+	newEngine, ok := consensusEngines[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown consensus engine %q", name)
+	}
+	return newEngine(genesis), nil
+}
+
+// ============================
+// Validator set
+// ============================
+
+// ValidatorSet is the set of validators known to a consensus engine
+type ValidatorSet struct {
+	validators []*Validator
+	mu         sync.RWMutex
+}
+
+// NewValidatorSet creates a validator set
+func NewValidatorSet(validators []*Validator) *ValidatorSet {
+	return &ValidatorSet{validators: validators}
+}
+
+// List returns the validators in registration order
+func (s *ValidatorSet) List() []*Validator {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]*Validator, len(s.validators))
+	copy(list, s.validators)
+	return list
+}
+
+// Len returns the number of validators
+func (s *ValidatorSet) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.validators)
+}
+
+// ByAddress returns the validator with the given address, or nil
+func (s *ValidatorSet) ByAddress(address string) *Validator {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, v := range s.validators {
+		if v.Address == address {
+			return v
+		}
+	}
+	return nil
+}
+
+// ByID returns the validator with the given ID, or nil
+func (s *ValidatorSet) ByID(id string) *Validator {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, v := range s.validators {
+		if v.ID == id {
+			return v
+		}
+	}
+	return nil
+}
+
+// AttachKey gives a validator its private key so this node can sign on its behalf
+func (s *ValidatorSet) AttachKey(id string, signer Signer) error {
+	validator := s.ByID(id)
+	if validator == nil {
+		return fmt.Errorf("validator %s is not in the validator set", id)
+	}
+	if validator.Address != AddressFromPublicKey(signer.Public()) {
+		return fmt.Errorf("key does not match the public key of validator %s", id)
+	}
+	validator.Signer = signer
+	return nil
+}
+
+// Active returns the validators allowed to propose and vote in the block after state: those
+// the chain has not jailed (see State.Validator), ordered by address, the order every node agrees on
+func (s *ValidatorSet) Active(state *State) []*Validator {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	active := make([]*Validator, 0, len(s.validators))
+	for _, v := range s.validators {
+		if record, ok := state.Validator(v.ID); ok && !record.Jailed {
+			active = append(active, v)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].Address < active[j].Address })
+	return active
+}
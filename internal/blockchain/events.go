@@ -0,0 +1,87 @@
+package blockchain
+
+import (
+	"sync"
+	"time"
+)
+
+// maxRecentReorgs bounds how many reorg events are kept for polling clients
+const maxRecentReorgs = 100
+
+// ReorgEvent describes a switch of the canonical chain to a different branch
+type ReorgEvent struct {
+	Seq        int      `json:"seq"`         // Increasing event number
+	Time       string   `json:"time"`        // When the reorg happened
+	OldTip     string   `json:"old_tip"`     // Hash of the previous head
+	NewTip     string   `json:"new_tip"`     // Hash of the new head
+	ForkHeight int      `json:"fork_height"` // First height where the branches differ
+	Orphaned   []string `json:"orphaned"`    // Hashes of blocks rolled back
+	Adopted    []string `json:"adopted"`     // Hashes of blocks applied
+	Requeued   []string `json:"requeued"`    // TxIDs returned to the mempool
+}
+
+// ReorgFeed keeps recent reorg events and fans them out to subscribers
+type ReorgFeed struct {
+	recent      []ReorgEvent
+	nextSeq     int
+	subscribers map[chan ReorgEvent]bool
+	mu          sync.Mutex
+}
+
+// NewReorgFeed creates an empty reorg feed
+func NewReorgFeed() *ReorgFeed {
+	return &ReorgFeed{
+		nextSeq:     1,
+		subscribers: make(map[chan ReorgEvent]bool),
+	}
+}
+
+// Publish records an event and delivers it to subscribers without blocking
+func (f *ReorgFeed) Publish(event ReorgEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	event.Seq = f.nextSeq
+	event.Time = time.Now().UTC().Format(time.RFC3339)
+	f.nextSeq++
+
+	f.recent = append(f.recent, event)
+	if len(f.recent) > maxRecentReorgs {
+		f.recent = f.recent[len(f.recent)-maxRecentReorgs:]
+	}
+
+	for ch := range f.subscribers {
+		select {
+		case ch <- event:
+		default: // Slow subscribers miss events rather than stall the chain
+		}
+	}
+}
+
+// Since returns the recorded events with a sequence number greater than seq
+func (f *ReorgFeed) Since(seq int) []ReorgEvent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	events := []ReorgEvent{}
+	for _, event := range f.recent {
+		if event.Seq > seq {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// Subscribe returns a channel receiving future events and a function to stop receiving them
+func (f *ReorgFeed) Subscribe() (<-chan ReorgEvent, func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ch := make(chan ReorgEvent, 16)
+	f.subscribers[ch] = true
+	return ch, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		delete(f.subscribers, ch)
+	}
+}
@@ -0,0 +1,103 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DuplicateVoteEvidence proves that a validator double signed: it signed two votes of the
+// same type, height and round for different blocks. Anyone holding the validator's public
+// key can check it, so every node reaches the same verdict from the signatures alone.
+type DuplicateVoteEvidence struct {
+	VoteA Vote // The vote with the smaller block hash
+	VoteB Vote
+}
+
+// NewDuplicateVoteEvidence builds evidence from two conflicting votes. The votes are ordered
+// by block hash, so the same offense always yields the same evidence (and TxID).
+func NewDuplicateVoteEvidence(a Vote, b Vote) (DuplicateVoteEvidence, error) {
+	if b.BlockHash < a.BlockHash {
+		a, b = b, a
+	}
+	evidence := DuplicateVoteEvidence{VoteA: a, VoteB: b}
+	if err := evidence.checkConflict(); err != nil {
+		return DuplicateVoteEvidence{}, err
+	}
+	return evidence, nil
+}
+
+// checkConflict checks that the votes are two different votes for the same step by one validator
+func (e DuplicateVoteEvidence) checkConflict() error {
+	a, b := e.VoteA, e.VoteB
+	if a.ValidatorID != b.ValidatorID {
+		return errors.New("votes are from different validators")
+	}
+	if a.Type != b.Type || a.Height != b.Height || a.Round != b.Round {
+		return errors.New("votes are for different steps")
+	}
+	if a.BlockHash >= b.BlockHash {
+		return errors.New("votes are not for different blocks in canonical order")
+	}
+	return nil
+}
+
+// Verify checks that the votes conflict and that both are signed by the validator's registered key
+func (e DuplicateVoteEvidence) Verify(validators *ValidatorSet) error {
+	if err := e.checkConflict(); err != nil {
+		return err
+	}
+	validator := validators.ByID(e.VoteA.ValidatorID)
+	if validator == nil {
+		return fmt.Errorf("unknown validator %s", e.VoteA.ValidatorID)
+	}
+	if !validator.VerifyVote(e.VoteA) || !validator.VerifyVote(e.VoteB) {
+		return fmt.Errorf("votes are not both signed by validator %s", validator.ID)
+	}
+	return nil
+}
+
+// NewEvidenceTransaction wraps evidence in a transaction. Evidence transactions carry no
+// file and need no submitter signature: the evidence authenticates itself.
+func NewEvidenceTransaction(evidence DuplicateVoteEvidence) Transaction {
+	tx := Transaction{Type: TxEvidence, Evidence: &evidence}
+	tx.TxID = tx.calculateTxID()
+	return tx
+}
+
+// SubmitEvidence verifies double-signing evidence and queues it in the mempool, to be
+// included in the next block this node proposes
+func (bc *Blockchain) SubmitEvidence(evidence DuplicateVoteEvidence) error {
+	if err := evidence.Verify(bc.Consensus.Validators()); err != nil {
+		return fmt.Errorf("invalid evidence: %w", err)
+	}
+	if v, _ := bc.State().Validator(evidence.VoteA.ValidatorID); v.Jailed {
+		return fmt.Errorf("validator %s is already jailed", v.ID)
+	}
+	for _, pending := range bc.Mempool.GetTransactions() {
+		if pending.Evidence != nil && pending.Evidence.VoteA.ValidatorID == evidence.VoteA.ValidatorID {
+			return fmt.Errorf("evidence against validator %s is already pending", evidence.VoteA.ValidatorID)
+		}
+	}
+
+	tx := NewEvidenceTransaction(evidence)
+	if err := bc.Mempool.AddTransaction(tx); err != nil {
+		return err
+	}
+	fmt.Printf("🚨 Evidence against validator %s queued: %s\n", evidence.VoteA.ValidatorID, tx.TxID)
+	return nil
+}
+
+// dropStaleEvidence drops pending evidence against validators the canonical chain already jailed
+func (bc *Blockchain) dropStaleEvidence() {
+	state := bc.State()
+	var stale []Transaction
+	for _, tx := range bc.Mempool.GetTransactions() {
+		if tx.Evidence == nil {
+			continue
+		}
+		if v, ok := state.Validator(tx.Evidence.VoteA.ValidatorID); !ok || v.Jailed {
+			stale = append(stale, tx)
+		}
+	}
+	bc.Mempool.RemoveTransactions(stale)
+}
@@ -0,0 +1,127 @@
+package blockchain
+
+import (
+	"strings"
+	"testing"
+)
+
+// testVote returns a prevote for blockHash at height 1 signed with signer as validator id
+func testVote(t *testing.T, id string, signer Signer, round int, blockHash string) Vote {
+	t.Helper()
+	validator := &Validator{ID: id, Signer: signer}
+	vote := Vote{Type: VotePrevote, Height: 1, Round: round, BlockHash: blockHash}
+	if err := validator.SignVote(&vote); err != nil {
+		t.Fatal(err)
+	}
+	return vote
+}
+
+func TestDuplicateVoteEvidenceVerify(t *testing.T) {
+	genesis, signers := testGenesisKeys(t, []int64{100, 100})
+	validators := NewValidatorSet(genesis.NewValidators())
+	hashA, hashB := testPrevHash(1), testPrevHash(2)
+
+	precommit := testVote(t, "v0", signers[0], 0, hashB)
+	precommit.Type = VotePrecommit
+	if err := (&Validator{ID: "v0", Signer: signers[0]}).SignVote(&precommit); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		a, b    Vote
+		wantErr string // Part of the error; empty for valid evidence
+	}{
+		{name: "conflicting votes", a: testVote(t, "v0", signers[0], 0, hashA), b: testVote(t, "v0", signers[0], 0, hashB)},
+		{name: "votes in either order", a: testVote(t, "v0", signers[0], 0, hashB), b: testVote(t, "v0", signers[0], 0, hashA)},
+		{name: "same block", a: testVote(t, "v0", signers[0], 0, hashA), b: testVote(t, "v0", signers[0], 0, hashA), wantErr: "different blocks"},
+		{name: "different rounds", a: testVote(t, "v0", signers[0], 0, hashA), b: testVote(t, "v0", signers[0], 1, hashB), wantErr: "different steps"},
+		{name: "different types", a: testVote(t, "v0", signers[0], 0, hashA), b: precommit, wantErr: "different steps"},
+		{name: "different validators", a: testVote(t, "v0", signers[0], 0, hashA), b: testVote(t, "v1", signers[1], 0, hashB), wantErr: "different validators"},
+		{name: "vote signed with another key", a: testVote(t, "v0", signers[0], 0, hashA), b: testVote(t, "v0", signers[1], 0, hashB), wantErr: "not both signed"},
+		{name: "unknown validator", a: testVote(t, "v9", signers[0], 0, hashA), b: testVote(t, "v9", signers[0], 0, hashB), wantErr: "unknown validator"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evidence, err := NewDuplicateVoteEvidence(tt.a, tt.b)
+			if err == nil {
+				err = evidence.Verify(validators)
+			}
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("verify: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("verify returned %v, expected %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyEvidenceJailsAndSlashes(t *testing.T) {
+	tests := []struct {
+		name        string
+		version     uint32
+		balance     int64
+		bonded      int64
+		wantBalance int64
+		wantBonded  int64
+		wantUnstake bool // Whether the jailed validator can still unstake
+	}{
+		// ✅ The stake is 100 from genesis plus the bond; 10% of it is slashed
+		{name: "bond first", version: BlockVersion7, balance: 100, bonded: 50, wantBalance: 100, wantBonded: 35},
+		{name: "bond then balance", version: BlockVersion7, balance: 100, bonded: 5, wantBalance: 95, wantBonded: 0},
+		{name: "down to zero", version: BlockVersion7, balance: 3, bonded: 5, wantBalance: 0, wantBonded: 0},
+		{name: "balance only before version 7", version: BlockVersion6, balance: 100, bonded: 50, wantBalance: 85, wantBonded: 50, wantUnstake: true},
+		{name: "balance down to zero before version 7", version: BlockVersion6, balance: 4, bonded: 50, wantBalance: 0, wantBonded: 50, wantUnstake: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			genesis, signers := testGenesisKeys(t, []int64{100, 100})
+			genesis.Upgrades = []NetworkUpgrade{{Name: "test", Height: 0, BlockVersion: tt.version}}
+			pod := NewPoDConsensusFromGenesis(genesis)
+			state := NewState(genesis)
+			state.height = 1
+			address := AddressFromPublicKey(signers[0].Public())
+			state.ledger.account(address).Balance = tt.balance
+			state.ledger.account(address).Bonded = tt.bonded
+
+			evidence, err := NewDuplicateVoteEvidence(testVote(t, "v0", signers[0], 0, testPrevHash(1)), testVote(t, "v0", signers[0], 0, testPrevHash(2)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tx := NewEvidenceTransaction(evidence)
+			if err := state.applyTransaction(tx); err != nil {
+				t.Fatalf("apply evidence: %v", err)
+			}
+
+			v, _ := state.Validator("v0")
+			if !v.Jailed || v.JailedAt != 1 {
+				t.Fatalf("validator is jailed %v at %d, expected jailed at 1", v.Jailed, v.JailedAt)
+			}
+			for _, active := range pod.Validators().Active(state) {
+				if active.ID == "v0" {
+					t.Fatal("jailed validator is still active")
+				}
+			}
+			if account := state.Account(address); account.Balance != tt.wantBalance || account.Bonded != tt.wantBonded {
+				t.Fatalf("balance %d, bonded %d; expected %d, %d", account.Balance, account.Bonded, tt.wantBalance, tt.wantBonded)
+			}
+
+			// ✅ A validator is convicted once
+			if err := state.applyTransaction(tx); err == nil {
+				t.Fatal("applied evidence against a jailed validator")
+			}
+
+			unstake := NewStakeTransaction(1, true)
+			unstake.Stake.Sender = Sender{From: address, Nonce: 1}
+			if tt.wantBonded == 0 {
+				state.ledger.account(address).Bonded = 1
+			}
+			if err := state.applyTransaction(unstake); (err == nil) != tt.wantUnstake {
+				t.Fatalf("unstake by a jailed validator returned %v, expected it allowed: %v", err, tt.wantUnstake)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package blockchain
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// DefaultFeeEstimateBlocks is how many recent blocks fee estimates look at by default
+const DefaultFeeEstimateBlocks = 20
+
+// Payer returns the address that pays the transaction's fee: the sender of an account
+// transaction or the uploader of a file. Coinbases and evidence have no payer.
+func (tx *Transaction) Payer() string {
+	if sender := tx.Sender(); sender != nil {
+		return sender.From
+	}
+	if tx.isFile() {
+		return tx.File.Uploader
+	}
+	return ""
+}
+
+// FeePerByte returns the fee the transaction pays per byte of its wire encoding (see Size);
+// blocks are filled with the highest paying transactions first
+func (tx *Transaction) FeePerByte() float64 {
+	return float64(tx.Fee) / float64(tx.Size())
+}
+
+// FeeFor returns the fee a transaction of size bytes pays at a fee per byte, rounded up
+func FeeFor(size int, feePerByte float64) int64 {
+	return int64(math.Ceil(feePerByte * float64(size)))
+}
+
+// payFee moves a transaction's fee from its payer to the proposer of the block being applied;
+// the state is unchanged if it fails
+func (s *State) payFee(tx Transaction) error {
+	payer := tx.Payer()
+	if s.proposer == "" {
+		return fmt.Errorf("block has no proposer to pay the fee of %d QRY to", tx.Fee)
+	}
+	if balance := s.ledger.Account(payer).Balance; tx.Fee > balance {
+		return fmt.Errorf("%w: %s holds %d QRY, fee is %d", ErrInsufficientBalance, payer, balance, tx.Fee)
+	}
+	s.ledger.account(payer).Balance -= tx.Fee
+	s.ledger.account(s.proposer).Balance += tx.Fee
+	return nil
+}
+
+// refundFee undoes payFee
+func (s *State) refundFee(tx Transaction) {
+	s.ledger.account(s.proposer).Balance -= tx.Fee
+	s.ledger.account(tx.Payer()).Balance += tx.Fee
+}
+
+// blockFees returns the fees a block paid its proposer
+func blockFees(block Block) int64 {
+	var fees int64
+	for _, tx := range block.Transactions {
+		fees += tx.Fee
+	}
+	return fees
+}
+
+// ============================
+// Fee estimates
+// ============================
+
+// FeeEstimate suggests fees per byte from the transactions of recent blocks
+type FeeEstimate struct {
+	Blocks        int     `json:"blocks"`           // Recent blocks looked at
+	Transactions  int     `json:"transactions"`     // Transactions in them, coinbases excluded
+	MinFeePerByte float64 `json:"min_fee_per_byte"` // Least this node admits (see Admission)
+	Low           float64 `json:"low"`              // 25th percentile of the fees per byte paid
+	Medium        float64 `json:"medium"`           // Median
+	High          float64 `json:"high"`             // 90th percentile
+}
+
+// EstimateFees returns fee per byte estimates from the last blocks of the canonical chain.
+// Without any transaction to look at, every estimate is the node's minimum.
+func (bc *Blockchain) EstimateFees(blocks int) FeeEstimate {
+	chain := bc.ChainSnapshot()
+
+	estimate := FeeEstimate{MinFeePerByte: bc.Admission.MinFeePerByte}
+	var rates []float64
+	for i := len(chain) - 1; i > 0 && estimate.Blocks < blocks; i-- {
+		estimate.Blocks++
+		for _, tx := range chain[i].Transactions {
+			if tx.Coinbase == nil {
+				rates = append(rates, tx.FeePerByte())
+			}
+		}
+	}
+	estimate.Transactions = len(rates)
+	sort.Float64s(rates)
+
+	estimate.Low = math.Max(percentile(rates, 25), estimate.MinFeePerByte)
+	estimate.Medium = math.Max(percentile(rates, 50), estimate.MinFeePerByte)
+	estimate.High = math.Max(percentile(rates, 90), estimate.MinFeePerByte)
+	return estimate
+}
+
+// percentile returns the p-th percentile of sorted values (nearest rank), or 0 for none
+func percentile(sorted []float64, p int) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(float64(p) / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
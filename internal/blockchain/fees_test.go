@@ -0,0 +1,112 @@
+package blockchain
+
+import (
+	"strings"
+	"testing"
+)
+
+// testAddress returns the address of a new wallet
+func testAddress(t *testing.T) string {
+	t.Helper()
+	wallet, err := NewWalletOfType(KeyTypeEd25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wallet.Address()
+}
+
+func TestApplyTransactionPaysFee(t *testing.T) {
+	alice, bob, proposer := testAddress(t), testAddress(t), testAddress(t)
+	genesis := testGenesis(t, []int64{100})
+	genesis.Allocations = []GenesisAllocation{{Address: alice, Amount: 100}}
+
+	transfer := func(nonce uint64, amount int64, fee int64) Transaction {
+		return Transaction{Type: TxTransfer, Fee: fee, Transfer: &Transfer{Sender: Sender{From: alice, Nonce: nonce}, To: bob, Amount: amount}}
+	}
+
+	tests := []struct {
+		name         string
+		proposer     string
+		transactions []Transaction
+		wantErr      string // Part of the last transaction's error; the others apply
+		wantBalances map[string]int64
+	}{
+		{
+			name:         "fee paid to the proposer",
+			proposer:     proposer,
+			transactions: []Transaction{transfer(1, 30, 5)},
+			wantBalances: map[string]int64{alice: 65, bob: 30, proposer: 5},
+		},
+		{
+			name:         "no fee",
+			proposer:     proposer,
+			transactions: []Transaction{transfer(1, 30, 0)},
+			wantBalances: map[string]int64{alice: 70, bob: 30, proposer: 0},
+		},
+		{
+			name:         "fees of every transaction",
+			proposer:     proposer,
+			transactions: []Transaction{transfer(1, 10, 5), transfer(2, 10, 7)},
+			wantBalances: map[string]int64{alice: 68, bob: 20, proposer: 12},
+		},
+		{
+			name:         "fee over the balance",
+			proposer:     proposer,
+			transactions: []Transaction{transfer(1, 1, 101)},
+			wantErr:      ErrInsufficientBalance.Error(),
+			wantBalances: map[string]int64{alice: 100, bob: 0, proposer: 0},
+		},
+		{
+			name:         "fee refunded when the transfer fails",
+			proposer:     proposer,
+			transactions: []Transaction{transfer(1, 96, 5)},
+			wantErr:      ErrInsufficientBalance.Error(),
+			wantBalances: map[string]int64{alice: 100, bob: 0, proposer: 0},
+		},
+		{
+			name:         "fee refunded after earlier fees",
+			proposer:     proposer,
+			transactions: []Transaction{transfer(1, 10, 5), transfer(3, 10, 5)},
+			wantErr:      "nonce 3",
+			wantBalances: map[string]int64{alice: 85, bob: 10, proposer: 5},
+		},
+		{
+			name:         "proposer paying its own fee",
+			proposer:     alice,
+			transactions: []Transaction{transfer(1, 30, 5)},
+			wantBalances: map[string]int64{alice: 70, bob: 30},
+		},
+		{
+			name:         "fee without a proposer",
+			transactions: []Transaction{transfer(1, 30, 5)},
+			wantErr:      "no proposer",
+			wantBalances: map[string]int64{alice: 100, bob: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := NewState(genesis)
+			state.height, state.proposer = 1, tt.proposer
+
+			var err error
+			for i, tx := range tt.transactions {
+				tx.TxID = tx.calculateTxID()
+				if err = state.applyTransaction(tx); err != nil && i < len(tt.transactions)-1 {
+					t.Fatalf("transaction %d: %v", i, err)
+				}
+			}
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("apply: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("apply returned %v, expected %q", err, tt.wantErr)
+			}
+			for address, want := range tt.wantBalances {
+				if got := state.Account(address).Balance; got != want {
+					t.Errorf("%s holds %d QRY, expected %d", address, got, want)
+				}
+			}
+		})
+	}
+}
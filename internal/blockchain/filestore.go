@@ -0,0 +1,314 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// fileStoreDir is the blob store directory inside the data directory
+const fileStoreDir = "files"
+
+// ErrFileNotFound is returned when no blob is stored under a hash
+var ErrFileNotFound = errors.New("file not found")
+
+// FileMeta describes a stored blob; the original filenames are metadata, not the storage key
+type FileMeta struct {
+	Hash  string   `json:"hash"`  // SHA-256 of the content (see HashFile)
+	Size  int64    `json:"size"`  // Content size in bytes
+	Names []string `json:"names"` // Original filenames the content was uploaded as
+	Refs  []string `json:"refs"`  // TxIDs of transactions referencing the content
+
+	Fingerprint string `json:"fingerprint,omitempty"` // ContentFingerprint of the first bytes (hex), for near-duplicate checks
+}
+
+// FsckIssue reports a problem found while re-checking the blob store
+type FsckIssue struct {
+	Path    string
+	Problem string
+}
+
+// FileStore is a content-addressed blob store keyed by SHA-256 with sharded directories
+// (files/ab/cd/abcd...). Identical content is stored once and reference counted per transaction.
+type FileStore struct {
+	root string
+	mu   sync.Mutex
+}
+
+// OpenFileStore opens (or creates) the blob store in dataDir
+func OpenFileStore(dataDir string) (*FileStore, error) {
+	root := filepath.Join(dataDir, fileStoreDir)
+	if err := os.MkdirAll(filepath.Join(root, "tmp"), 0o755); err != nil {
+		return nil, fmt.Errorf("create file store: %w", err)
+	}
+	return &FileStore{root: root}, nil
+}
+
+// Path returns the sharded location of the blob with the given hash
+func (fs *FileStore) Path(hash string) string {
+	if len(hash) < 4 {
+		return filepath.Join(fs.root, hash)
+	}
+	return filepath.Join(fs.root, hash[0:2], hash[2:4], hash)
+}
+
+func (fs *FileStore) metaPath(hash string) string {
+	return fs.Path(hash) + ".json"
+}
+
+// Put streams content into the store and returns its metadata. Content that is
+// already stored is deduplicated; only the filename is recorded.
+func (fs *FileStore) Put(content io.Reader, filename string) (FileMeta, error) {
+	tmp, err := os.CreateTemp(filepath.Join(fs.root, "tmp"), "upload-*")
+	if err != nil {
+		return FileMeta{}, fmt.Errorf("create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op once the blob has been moved into place
+
+	// ✅ Keep the first bytes to fingerprint the content without reading it again
+	sample := &limitedBuffer{limit: trustSampleBytes}
+	size, err := io.Copy(io.MultiWriter(tmp, sample), content)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return FileMeta{}, fmt.Errorf("write temp file: %w", err)
+	}
+
+	// ✅ The storage key is exactly the hash recorded in the transaction
+	hash, err := HashFile(tmpPath)
+	if err != nil {
+		return FileMeta{}, fmt.Errorf("hash file: %w", err)
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	meta, err := fs.readMeta(hash)
+	if errors.Is(err, ErrFileNotFound) {
+		meta = FileMeta{Hash: hash, Size: size}
+	} else if err != nil {
+		return FileMeta{}, err
+	}
+
+	blobPath := fs.Path(hash)
+	if _, err := os.Stat(blobPath); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(blobPath), 0o755); err != nil {
+			return FileMeta{}, fmt.Errorf("create shard directory: %w", err)
+		}
+		if err := os.Rename(tmpPath, blobPath); err != nil {
+			return FileMeta{}, fmt.Errorf("store blob: %w", err)
+		}
+	} else {
+		fmt.Printf("♻ File %s already stored, deduplicated\n", hash)
+	}
+
+	if meta.Fingerprint == "" && len(sample.buf) >= fingerprintMinBytes {
+		meta.Fingerprint = strconv.FormatUint(ContentFingerprint(sample.buf), 16)
+	}
+	if filename != "" && !containsString(meta.Names, filename) {
+		meta.Names = append(meta.Names, filename)
+	}
+	if err := fs.writeMeta(meta); err != nil {
+		return FileMeta{}, err
+	}
+	return meta, nil
+}
+
+// Open opens the blob with the given hash for reading
+func (fs *FileStore) Open(hash string) (*os.File, error) {
+	file, err := os.Open(fs.Path(hash))
+	if os.IsNotExist(err) {
+		return nil, ErrFileNotFound
+	}
+	return file, err
+}
+
+// Meta returns the metadata of the blob with the given hash
+func (fs *FileStore) Meta(hash string) (FileMeta, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	return fs.readMeta(hash)
+}
+
+// AddRef records that a transaction references the blob
+func (fs *FileStore) AddRef(hash string, txID string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	meta, err := fs.readMeta(hash)
+	if err != nil {
+		return err
+	}
+	if containsString(meta.Refs, txID) {
+		return nil
+	}
+	meta.Refs = append(meta.Refs, txID)
+	return fs.writeMeta(meta)
+}
+
+// Release drops a transaction's reference and deletes the blob once nothing references it
+func (fs *FileStore) Release(hash string, txID string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	meta, err := fs.readMeta(hash)
+	if err != nil {
+		return err
+	}
+
+	refs := meta.Refs[:0]
+	for _, ref := range meta.Refs {
+		if ref != txID {
+			refs = append(refs, ref)
+		}
+	}
+	meta.Refs = refs
+
+	if len(meta.Refs) > 0 {
+		return fs.writeMeta(meta)
+	}
+
+	// ✅ Last reference gone: remove content and metadata
+	if err := os.Remove(fs.Path(hash)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(fs.metaPath(hash)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	fmt.Printf("🗑 File %s released (no references left)\n", hash)
+	return nil
+}
+
+// Fsck re-hashes every stored blob and reports mismatches, missing metadata and leftovers
+func (fs *FileStore) Fsck() ([]FsckIssue, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	var issues []FsckIssue
+	report := func(path, problem string) {
+		issues = append(issues, FsckIssue{Path: path, Problem: problem})
+	}
+
+	err := filepath.Walk(fs.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(fs.root, path)
+		if strings.HasPrefix(rel, "tmp"+string(filepath.Separator)) || strings.HasSuffix(rel, ".tmp") {
+			report(rel, "leftover temporary file from an interrupted upload")
+			return nil
+		}
+
+		name := info.Name()
+		if hash, ok := strings.CutSuffix(name, ".json"); ok {
+			if _, err := os.Stat(fs.Path(hash)); os.IsNotExist(err) {
+				report(rel, "metadata without content")
+			}
+			return nil
+		}
+
+		if fs.Path(name) != path {
+			report(rel, "blob stored outside its shard directory")
+			return nil
+		}
+
+		hash, err := HashFile(path)
+		if err != nil {
+			report(rel, "unreadable: "+err.Error())
+			return nil
+		}
+		if hash != name {
+			report(rel, "content hash mismatch: "+hash)
+			return nil
+		}
+
+		meta, err := fs.readMeta(name)
+		if err != nil {
+			report(rel, "missing or unreadable metadata")
+			return nil
+		}
+		if meta.Size != info.Size() {
+			report(rel, fmt.Sprintf("size mismatch: metadata says %d, content is %d", meta.Size, info.Size()))
+		}
+		return nil
+	})
+
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Path < issues[j].Path })
+	return issues, err
+}
+
+func (fs *FileStore) readMeta(hash string) (FileMeta, error) {
+	var meta FileMeta
+	data, err := os.ReadFile(fs.metaPath(hash))
+	if os.IsNotExist(err) {
+		return meta, ErrFileNotFound
+	}
+	if err != nil {
+		return meta, err
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return meta, fmt.Errorf("decode metadata for %s: %w", hash, err)
+	}
+	return meta, nil
+}
+
+// writeMeta replaces the metadata file atomically (write temp file, then rename)
+func (fs *FileStore) writeMeta(meta FileMeta) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := fs.metaPath(meta.Hash)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards the rest
+type limitedBuffer struct {
+	buf   []byte
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - len(b.buf); room > 0 {
+		if len(p) < room {
+			room = len(p)
+		}
+		b.buf = append(b.buf, p[:room]...)
+	}
+	return len(p), nil
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
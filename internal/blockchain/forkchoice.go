@@ -0,0 +1,320 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrUnknownParent is returned when a block's parent is not in the block tree
+var ErrUnknownParent = errors.New("unknown parent block")
+
+// stateCacheDepth is how many heights below the newest block the tree keeps states for;
+// blocks extending older ones have their parent's state replayed
+const stateCacheDepth = 64
+
+// BlockTree tracks every known valid block, including side branches that lost fork choice,
+// and the state after each recent block so new blocks are applied to their parent's state
+type BlockTree struct {
+	blocks map[string]Block  // Block by hash
+	tips   map[string]bool   // Hashes of blocks without children
+	states map[string]*State // State after the block, for blocks within stateCacheDepth of the newest
+	mu     sync.Mutex
+}
+
+// NewBlockTree creates a tree holding the given chain
+func NewBlockTree(chain []Block) *BlockTree {
+	tree := &BlockTree{
+		blocks: make(map[string]Block),
+		tips:   make(map[string]bool),
+		states: make(map[string]*State),
+	}
+	for _, block := range chain {
+		tree.add(block, nil)
+	}
+	return tree
+}
+
+// Has reports whether the block with the given hash is known
+func (t *BlockTree) Has(hash string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	_, ok := t.blocks[hash]
+	return ok
+}
+
+// Get returns the block with the given hash
+func (t *BlockTree) Get(hash string) (Block, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	block, ok := t.blocks[hash]
+	return block, ok
+}
+
+// State returns the cached state after the block with the given hash, or nil. The state is
+// shared and must not be modified.
+func (t *BlockTree) State(hash string) *State {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.states[hash]
+}
+
+// SetState caches the state after a known block
+func (t *BlockTree) SetState(hash string, state *State) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.blocks[hash]; ok {
+		t.states[hash] = state
+	}
+}
+
+// Add inserts an already validated block whose parent is known (or a genesis block), with
+// the state after it
+func (t *BlockTree) Add(block Block, state *State) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.blocks[block.PreviousHash]; !ok && block.Index != 0 {
+		return ErrUnknownParent
+	}
+	t.add(block, state)
+	return nil
+}
+
+func (t *BlockTree) add(block Block, state *State) {
+	if _, ok := t.blocks[block.Hash]; ok {
+		return
+	}
+	t.blocks[block.Hash] = block
+	delete(t.tips, block.PreviousHash)
+	t.tips[block.Hash] = true
+	if state == nil {
+		return
+	}
+
+	// ✅ Keep states near the newest block only, so memory does not grow with the chain
+	t.states[block.Hash] = state
+	for hash := range t.states {
+		if t.blocks[hash].Index < block.Index-stateCacheDepth {
+			delete(t.states, hash)
+		}
+	}
+}
+
+// Branch returns the chain from genesis up to and including the given block
+func (t *BlockTree) Branch(hash string) ([]Block, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	block, ok := t.blocks[hash]
+	if !ok {
+		return nil, fmt.Errorf("block %s not in tree", hash)
+	}
+
+	branch := make([]Block, block.Index+1)
+	for {
+		branch[block.Index] = block
+		if block.Index == 0 {
+			return branch, nil
+		}
+		if block, ok = t.blocks[block.PreviousHash]; !ok {
+			return nil, ErrUnknownParent
+		}
+	}
+}
+
+// BestTip applies the fork-choice rule: the longest chain wins, and among equally
+// long chains the tip with the lexicographically smallest hash wins, so every node
+// holding the same blocks picks the same head
+func (t *BlockTree) BestTip() Block {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var best Block
+	found := false
+	for hash := range t.tips {
+		tip := t.blocks[hash]
+		if !found || tip.Index > best.Index || (tip.Index == best.Index && tip.Hash < best.Hash) {
+			best = tip
+			found = true
+		}
+	}
+	return best
+}
+
+// extendTree validates blocks against their parent's state and adds them to the tree, then
+// switches the canonical chain to the fork-choice winner. Caller must hold bc.mu.
+func (bc *Blockchain) extendTree(blocks []Block) error {
+	for _, block := range blocks {
+		if bc.Tree.Has(block.Hash) {
+			continue
+		}
+
+		state, err := bc.ValidateBlock(block)
+		if err != nil {
+			return err
+		}
+		if err := bc.Tree.Add(block, state); err != nil {
+			return err
+		}
+	}
+
+	return bc.updateHead()
+}
+
+// stateAfter returns a copy of the state after a block of the tree, which the caller may
+// modify. Blocks without a cached state are applied on top of their closest ancestor's.
+func (bc *Blockchain) stateAfter(hash string) (*State, error) {
+	var pending []Block
+	for {
+		if state := bc.Tree.State(hash); state != nil {
+			return replayOnto(state.Clone(), pending)
+		}
+		block, ok := bc.Tree.Get(hash)
+		if !ok {
+			return nil, ErrUnknownParent
+		}
+		pending = append(pending, block)
+		if block.Index == 0 {
+			return replayOnto(NewState(bc.Genesis), pending)
+		}
+		hash = block.PreviousHash
+	}
+}
+
+// replayOnto applies blocks, listed newest first, to state
+func replayOnto(state *State, blocks []Block) (*State, error) {
+	for i := len(blocks) - 1; i >= 0; i-- {
+		if err := state.applyBlock(blocks[i]); err != nil {
+			return nil, fmt.Errorf("replay block #%d: %w", blocks[i].Index, err)
+		}
+	}
+	return state, nil
+}
+
+// updateHead reorganizes the canonical chain onto the best tip if it changed.
+// Orphaned blocks are rolled back and their transactions returned to the mempool if
+// they pass admission again (see Blockchain.AddTransaction).
+func (bc *Blockchain) updateHead() error {
+	best := bc.Tree.BestTip()
+	current := bc.Chain[len(bc.Chain)-1]
+	if best.Hash == current.Hash {
+		return nil
+	}
+
+	newChain, err := bc.Tree.Branch(best.Hash)
+	if err != nil {
+		return err
+	}
+
+	fork := 0
+	for fork < len(bc.Chain) && fork < len(newChain) && bc.Chain[fork].Hash == newChain[fork].Hash {
+		fork++
+	}
+	orphaned := bc.Chain[fork:]
+	adopted := newChain[fork:]
+
+	// ✅ The new head's state was computed when the block was validated
+	state := bc.Tree.State(best.Hash)
+	if state == nil {
+		if state, err = bc.stateAfter(best.Hash); err != nil {
+			return err
+		}
+		bc.Tree.SetState(best.Hash, state)
+	}
+
+	// ✅ Rewrite the durable log first so a crash never leaves a half-applied reorg in memory
+	if err := bc.Store.TruncateTo(fork); err != nil {
+		return err
+	}
+	for _, block := range adopted {
+		if err := bc.Store.Append(block); err != nil {
+			return err
+		}
+	}
+	bc.setHead(newChain, state)
+	if bc.BFT != nil {
+		bc.BFT.advance(best) // ✅ Vote on the block after the new head
+	}
+
+	// ✅ Drop adopted transactions from the mempool and requeue the ones only orphaned blocks had
+	adoptedFiles := make(map[string]string) // TxID by file hash
+	adoptedEvidence := make(map[string]bool)
+	for _, block := range adopted {
+		bc.Mempool.RemoveTransactions(block.Transactions)
+		for _, tx := range block.Transactions {
+			if tx.Evidence != nil {
+				adoptedEvidence[tx.Evidence.VoteA.ValidatorID] = true
+			} else if tx.isFile() {
+				adoptedFiles[tx.File.FileHash] = tx.TxID
+			}
+		}
+	}
+	var requeued []string
+	for _, block := range orphaned {
+		for _, tx := range block.Transactions {
+			if tx.Coinbase != nil {
+				continue // ✅ Rewards belong to the block that minted them
+			}
+			if tx.Evidence != nil && adoptedEvidence[tx.Evidence.VoteA.ValidatorID] {
+				continue
+			}
+			if tx.isFile() {
+				if adopted, ok := adoptedFiles[tx.File.FileHash]; ok {
+					bc.moveFileRef(tx, adopted) // ✅ The stored content now belongs to the adopted notarization
+					continue
+				}
+			}
+			if bc.Mempool.Has(tx.TxID) {
+				continue
+			}
+			// ✅ Orphaned transactions are admitted again on top of the new chain (see requeueTransaction)
+			var err error
+			if tx.Evidence != nil {
+				err = bc.SubmitEvidence(*tx.Evidence)
+			} else {
+				_, err = bc.requeueTransaction(tx)
+			}
+			if err != nil {
+				fmt.Printf("⚠ Orphaned transaction %s not requeued: %v\n", tx.TxID, err)
+				bc.releaseFile(tx)
+				continue
+			}
+			requeued = append(requeued, tx.TxID)
+		}
+	}
+
+	// ✅ The new chain decides which validators are jailed
+	bc.dropStaleEvidence()
+
+	if len(orphaned) == 0 {
+		fmt.Printf("⛓ Chain extended to block #%d\n", best.Index)
+		return nil
+	}
+
+	event := ReorgEvent{
+		OldTip:     current.Hash,
+		NewTip:     best.Hash,
+		ForkHeight: fork,
+		Orphaned:   blockHashes(orphaned),
+		Adopted:    blockHashes(adopted),
+		Requeued:   requeued,
+	}
+	fmt.Printf("🔀 Reorg at height %d: %d block(s) orphaned, %d adopted, %d transaction(s) requeued\n",
+		fork, len(orphaned), len(adopted), len(requeued))
+	bc.Reorgs.Publish(event)
+	return nil
+}
+
+// blockHashes lists the hashes of the given blocks
+func blockHashes(blocks []Block) []string {
+	hashes := make([]string, len(blocks))
+	for i, block := range blocks {
+		hashes[i] = block.Hash
+	}
+	return hashes
+}
@@ -0,0 +1,190 @@
+package blockchain
+
+import (
+	"slices"
+	"testing"
+)
+
+// testBlockchain opens a node in a new data directory for genesis, holding the key of validator v0
+func testBlockchain(t *testing.T, genesis *Genesis, key Signer) *Blockchain {
+	t.Helper()
+	dir := t.TempDir()
+	if err := InitDataDir(dir, genesis); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveValidatorKey(dir, "v0", key); err != nil {
+		t.Fatal(err)
+	}
+	bc, err := NewBlockchain("", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { bc.Store.Close() })
+	return bc
+}
+
+// testBlock builds a block on top of parent signed by key; state is the state after parent. It
+// returns the block and the state after it.
+func testBlock(t *testing.T, bc *Blockchain, parent Block, state *State, key Signer, transactions ...Transaction) (Block, *State) {
+	t.Helper()
+	header := bc.Config.NewHeader(parent.Index+1, parent.Hash)
+	header.Proposer = AddressFromPublicKey(key.Public())
+	next, err := ApplyBlock(state, Block{BlockHeader: header, Transactions: transactions})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header.StateRoot = next.Root()
+	return NewBlock(header, transactions, &Wallet{Signer: key}), next
+}
+
+// testSignedTransfer signs a transfer of amount QRY from wallet to to, paying a fee of 10 QRY
+func testSignedTransfer(t *testing.T, wallet *Wallet, to string, amount int64, nonce uint64) Transaction {
+	t.Helper()
+	tx, err := NewTransferTransaction(wallet, to, amount, 10, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+func TestBlockTreeBestTip(t *testing.T) {
+	genesis := Block{BlockHeader: BlockHeader{Index: 0}, Hash: "g"}
+	block := func(index int, hash string, parent string) Block {
+		return Block{BlockHeader: BlockHeader{Index: index, PreviousHash: parent}, Hash: hash}
+	}
+
+	tests := []struct {
+		name   string
+		blocks []Block
+		want   string
+	}{
+		{name: "genesis only", want: "g"},
+		{name: "single branch", blocks: []Block{block(1, "b1", "g"), block(2, "b2", "b1")}, want: "b2"},
+		{name: "equal length picks the smallest hash", blocks: []Block{block(1, "b", "g"), block(1, "a", "g"), block(1, "c", "g")}, want: "a"},
+		{name: "tie-break ignores arrival order", blocks: []Block{block(1, "a", "g"), block(1, "b", "g")}, want: "a"},
+		{name: "longer chain beats a smaller hash", blocks: []Block{block(1, "a", "g"), block(1, "z1", "g"), block(2, "z2", "z1")}, want: "z2"},
+		{name: "extended tip is no longer a tip", blocks: []Block{block(1, "a", "g"), block(2, "y", "a"), block(1, "b", "g")}, want: "y"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := NewBlockTree([]Block{genesis})
+			for _, b := range tt.blocks {
+				if err := tree.Add(b, nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if best := tree.BestTip(); best.Hash != tt.want {
+				t.Fatalf("best tip %s, expected %s", best.Hash, tt.want)
+			}
+		})
+	}
+}
+
+func TestReorganizationRequeuesTransactions(t *testing.T) {
+	genesis, keys := testGenesisKeys(t, []int64{100})
+	genesis.Params.Consensus = EngineDev
+	alice, _ := NewWalletOfType(KeyTypeEd25519)
+	bob, _ := NewWalletOfType(KeyTypeEd25519)
+	carol := testAddress(t)
+	genesis.Allocations = []GenesisAllocation{{Address: alice.Address(), Amount: 1000}, {Address: bob.Address(), Amount: 1000}}
+
+	aliceFirst := testSignedTransfer(t, alice, carol, 100, 1)  // Canonical branch only
+	bobFirst := testSignedTransfer(t, bob, carol, 50, 1)       // Canonical branch only; still applies on the side branch
+	aliceOther := testSignedTransfer(t, alice, carol, 200, 1)  // Side branch, same nonce as aliceFirst
+	aliceSecond := testSignedTransfer(t, alice, carol, 300, 2) // Side branch
+
+	tests := []struct {
+		name         string
+		canonical    [][]Transaction // Transactions of each block of the canonical branch
+		side         [][]Transaction // Transactions of each block of the side branch
+		wantSide     bool            // Whether the side branch wins
+		wantRequeued []string
+		wantCarol    int64 // Carol's balance after the head
+	}{
+		{
+			name:         "longer side branch",
+			canonical:    [][]Transaction{{aliceFirst, bobFirst}},
+			side:         [][]Transaction{{aliceOther}, {aliceSecond}},
+			wantSide:     true,
+			wantRequeued: testTxIDs(bobFirst),
+			wantCarol:    500,
+		},
+		{
+			name:      "shorter side branch",
+			canonical: [][]Transaction{{aliceFirst, bobFirst}, {aliceSecond}},
+			side:      [][]Transaction{{aliceOther}},
+			wantCarol: 450,
+		},
+		{
+			name:      "every orphaned transaction is in the new branch",
+			canonical: [][]Transaction{{aliceFirst}},
+			side:      [][]Transaction{{aliceFirst, bobFirst}, {aliceSecond}},
+			wantSide:  true,
+			wantCarol: 450,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bc := testBlockchain(t, genesis, keys[0])
+			reorgs, cancel := bc.Reorgs.Subscribe()
+			defer cancel()
+
+			// ✅ The side branch arrives at once, like a chain sent by a peer
+			head, state := bc.Chain[0], NewState(genesis)
+			for _, transactions := range tt.canonical {
+				head, state = testBlock(t, bc, head, state, keys[0], transactions...)
+				if err := bc.AcceptBlock(head); err != nil {
+					t.Fatal(err)
+				}
+			}
+			side := []Block{bc.Chain[0]}
+			state = NewState(genesis)
+			for _, transactions := range tt.side {
+				var block Block
+				block, state = testBlock(t, bc, side[len(side)-1], state, keys[0], transactions...)
+				side = append(side, block)
+			}
+			if err := bc.ReceiveChain(side); err != nil {
+				t.Fatal(err)
+			}
+			sideTip := side[len(side)-1]
+
+			want := head
+			if tt.wantSide {
+				want = sideTip
+			}
+			head, state = bc.Head()
+			if head.Hash != want.Hash {
+				t.Fatalf("head is block #%d %s, expected #%d %s", head.Index, head.Hash, want.Index, want.Hash)
+			}
+			if got := state.Account(carol).Balance; got != tt.wantCarol {
+				t.Fatalf("carol holds %d QRY after the head, expected %d", got, tt.wantCarol)
+			}
+			if !bc.Tree.Has(sideTip.Hash) {
+				t.Fatal("side branch is not kept in the block tree")
+			}
+
+			select {
+			case event := <-reorgs:
+				if !tt.wantSide {
+					t.Fatalf("reorganized onto %s", event.NewTip)
+				}
+				if event.NewTip != want.Hash || event.ForkHeight != 1 {
+					t.Fatalf("reorg to %s at height %d, expected %s at 1", event.NewTip, event.ForkHeight, want.Hash)
+				}
+				if !slices.Equal(event.Requeued, tt.wantRequeued) {
+					t.Fatalf("requeued %v, expected %v", event.Requeued, tt.wantRequeued)
+				}
+			default:
+				if tt.wantSide {
+					t.Fatal("no reorg event")
+				}
+			}
+			if got := testTxIDs(bc.Mempool.GetTransactions()...); !slices.Equal(got, tt.wantRequeued) {
+				t.Fatalf("mempool holds %v, expected %v", got, tt.wantRequeued)
+			}
+		})
+	}
+}
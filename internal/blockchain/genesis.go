@@ -0,0 +1,321 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// GenesisFileName is the genesis file inside the data directory
+const GenesisFileName = "genesis.json"
+
+// Files of data directories created before genesis files (see MigrateLegacyDataDir)
+const (
+	legacyConfigName = "chain.json" // Chain ID and upgrade schedule, replaced by the genesis file
+	legacyDirName    = "legacy"     // Where MigrateLegacyDataDir archives them
+)
+
+// ErrLegacyDataDir is returned when a data directory holds a chain but no genesis file
+var ErrLegacyDataDir = errors.New("data directory holds a chain from before genesis files")
+
+// Genesis describes the initial state of a network. Every node of a network uses the
+// same file, so they all derive the same genesis block and can agree on one chain.
+type Genesis struct {
+	ChainID     string              `json:"chain_id"`
+	GenesisTime string              `json:"genesis_time"` // RFC 3339, used as the genesis block timestamp
+	Validators  []GenesisValidator  `json:"validators"`
+	Allocations []GenesisAllocation `json:"allocations"` // Initial QRY balances
+	Params      ChainParams         `json:"params"`
+	Upgrades    []NetworkUpgrade    `json:"upgrades"`
+}
+
+// GenesisValidator is a validator of the initial validator set
+type GenesisValidator struct {
+	ID        string  `json:"id"`
+	KeyType   KeyType `json:"key_type"`
+	PublicKey string  `json:"public_key"` // Hex, as returned by EncodePublicKey
+	Stake     int64   `json:"stake"`
+}
+
+// GenesisAllocation credits an address with QRY tokens at genesis
+type GenesisAllocation struct {
+	Address string `json:"address"`
+	Amount  int64  `json:"amount"`
+}
+
+// ChainParams are the consensus parameters of a network
+type ChainParams struct {
+	Consensus         string `json:"consensus"`          // Consensus engine: "pod" (default), "poa" or "dev"
+	ApprovalThreshold int    `json:"approval_threshold"` // Percentage of voting power that must commit a block (PoD requires more than 2/3 anyway)
+	BlockReward       int    `json:"block_reward"`       // QRY paid to each committing validator
+	MaxBlockBytes     int    `json:"max_block_bytes"`    // Limit on the encoded size of a block's transactions
+	SlashPercent      int    `json:"slash_percent"`      // Share of a double-signing validator's stake deducted from its balance
+}
+
+// DefaultChainParams returns the parameters of development networks
+func DefaultChainParams() ChainParams {
+	return ChainParams{
+		Consensus:         EnginePoD,
+		ApprovalThreshold: 75,
+		BlockReward:       10,
+		MaxBlockBytes:     1 << 20,
+		SlashPercent:      10,
+	}
+}
+
+// Validate checks that the parameters are usable
+func (p ChainParams) Validate() error {
+	if _, ok := consensusEngines[p.Consensus]; p.Consensus != "" && !ok {
+		return fmt.Errorf("unknown consensus engine %q", p.Consensus)
+	}
+	if p.ApprovalThreshold < 1 || p.ApprovalThreshold > 100 {
+		return fmt.Errorf("approval threshold %d%% is not between 1 and 100", p.ApprovalThreshold)
+	}
+	if p.BlockReward < 0 {
+		return errors.New("block reward is negative")
+	}
+	if p.MaxBlockBytes <= 0 {
+		return errors.New("max block bytes must be positive")
+	}
+	if p.SlashPercent < 0 || p.SlashPercent > 100 {
+		return fmt.Errorf("slash percent %d%% is not between 0 and 100", p.SlashPercent)
+	}
+	return nil
+}
+
+// NewDevGenesis creates a single-validator development network led by the given key
+func NewDevGenesis(validatorID string, validatorKey Verifier) *Genesis {
+	return &Genesis{
+		ChainID:     "pod-devnet",
+		GenesisTime: time.Now().UTC().Format(time.RFC3339),
+		Validators: []GenesisValidator{{
+			ID:        validatorID,
+			KeyType:   validatorKey.KeyType(),
+			PublicKey: EncodePublicKey(validatorKey),
+			Stake:     100,
+		}},
+		Allocations: []GenesisAllocation{{
+			Address: AddressFromPublicKey(validatorKey),
+			Amount:  1000,
+		}},
+		Params:   DefaultChainParams(),
+		Upgrades: DefaultUpgrades(),
+	}
+}
+
+// LoadGenesis reads and validates a genesis file
+func LoadGenesis(path string) (*Genesis, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read genesis: %w", err)
+	}
+
+	var genesis Genesis
+	if err := json.Unmarshal(data, &genesis); err != nil {
+		return nil, fmt.Errorf("decode genesis: %w", err)
+	}
+	if err := genesis.Validate(); err != nil {
+		return nil, fmt.Errorf("genesis: %w", err)
+	}
+	return &genesis, nil
+}
+
+// Save writes the genesis file; it never overwrites an existing one
+func (g *Genesis) Save(path string) error {
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Validate checks the genesis time, validator keys, allocations, parameters and upgrade schedule
+func (g *Genesis) Validate() error {
+	if _, err := time.Parse(time.RFC3339, g.GenesisTime); err != nil {
+		return fmt.Errorf("genesis time: %w", err)
+	}
+	if err := g.Config().Validate(); err != nil {
+		return err
+	}
+
+	if len(g.Validators) == 0 {
+		return errors.New("no validators")
+	}
+	seen := make(map[string]bool)
+	for _, v := range g.Validators {
+		if v.ID == "" || seen[v.ID] {
+			return fmt.Errorf("validator ID %q is empty or duplicated", v.ID)
+		}
+		seen[v.ID] = true
+		if _, err := ParsePublicKey(v.KeyType, v.PublicKey); err != nil {
+			return fmt.Errorf("validator %s: %w", v.ID, err)
+		}
+		if v.Stake <= 0 {
+			return fmt.Errorf("validator %s: stake must be positive", v.ID)
+		}
+	}
+
+	for _, allocation := range g.Allocations {
+		if err := ValidateAddress(allocation.Address); err != nil {
+			return fmt.Errorf("allocation to %q: %w", allocation.Address, err)
+		}
+		if allocation.Amount <= 0 {
+			return fmt.Errorf("allocation to %s: amount must be positive", allocation.Address)
+		}
+	}
+	return nil
+}
+
+// Config returns the chain configuration defined by the genesis file
+func (g *Genesis) Config() ChainConfig {
+	return ChainConfig{
+		ChainID:  g.ChainID,
+		Params:   g.Params,
+		Upgrades: g.Upgrades,
+	}
+}
+
+// Hash returns the SHA-256 of the canonical genesis encoding (hex)
+func (g *Genesis) Hash() string {
+	hash := sha256.Sum256(g.CanonicalBytes())
+	return hex.EncodeToString(hash[:])
+}
+
+// Block returns the genesis block. It is unsigned and links to the genesis file hash,
+// so its hash commits to the whole genesis file (and, from version 4, to the genesis state).
+func (g *Genesis) Block() Block {
+	header := g.Config().NewHeader(0, g.Hash())
+	header.Timestamp = g.GenesisTime
+	header.MerkleRoot = MerkleRoot(nil)
+	if g.Config().RulesAt(0).StateRoot {
+		header.StateRoot = NewState(g).Root()
+	}
+	hash, _ := header.ComputeHash()
+	return Block{
+		BlockHeader:  header,
+		Transactions: []Transaction{},
+		Hash:         hash,
+	}
+}
+
+// NewValidators returns the initial validator set; validators have no signing key
+// until one is attached from the node's validator key file
+func (g *Genesis) NewValidators() []*Validator {
+	validators := make([]*Validator, 0, len(g.Validators))
+	for _, v := range g.Validators {
+		publicKey, err := ParsePublicKey(v.KeyType, v.PublicKey)
+		if err != nil {
+			continue // Rejected by Validate
+		}
+		validators = append(validators, &Validator{
+			ID:        v.ID,
+			Stake:     v.Stake,
+			KeyType:   v.KeyType,
+			PublicKey: EncodePublicKey(publicKey),
+			Address:   AddressFromPublicKey(publicKey),
+		})
+	}
+	return validators
+}
+
+// InitDataDir creates a data directory for the network described by genesis:
+// it writes the genesis file and the genesis block
+func InitDataDir(dataDir string, genesis *Genesis) error {
+	if err := genesis.Validate(); err != nil {
+		return fmt.Errorf("genesis: %w", err)
+	}
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return fmt.Errorf("create data directory: %w", err)
+	}
+
+	store, err := OpenBlockStore(dataDir)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	if store.Len() > 0 {
+		return fmt.Errorf("%s already contains a chain", dataDir)
+	}
+
+	if err := genesis.Save(filepath.Join(dataDir, GenesisFileName)); err != nil {
+		return fmt.Errorf("write genesis: %w", err)
+	}
+	if err := store.Append(genesis.Block()); err != nil {
+		return err
+	}
+	fmt.Printf("🌱 Initialized %s for chain %s (genesis %s)\n", dataDir, genesis.ChainID, genesis.Block().Hash)
+	return nil
+}
+
+// InitDevDataDir creates a data directory for a new single-validator development network
+// running the given consensus engine; the validator key is written to the data directory
+// so the node can propose and approve blocks
+func InitDevDataDir(dataDir string, consensus string) (*Genesis, error) {
+	signer, err := GenerateSigner(KeyTypeP256)
+	if err != nil {
+		return nil, err
+	}
+
+	genesis := NewDevGenesis("validator-1", signer.Public())
+	genesis.Params.Consensus = consensus
+	if err := InitDataDir(dataDir, genesis); err != nil {
+		return nil, err
+	}
+	if err := SaveValidatorKey(dataDir, "validator-1", signer); err != nil {
+		return nil, err
+	}
+	return genesis, nil
+}
+
+// IsLegacyDataDir reports whether a data directory was created before genesis files: it has
+// no genesis file but holds blocks or a chain.json
+func IsLegacyDataDir(dataDir string) bool {
+	if _, err := os.Stat(filepath.Join(dataDir, GenesisFileName)); !errors.Is(err, os.ErrNotExist) {
+		return false
+	}
+	if info, err := os.Stat(filepath.Join(dataDir, blockLogName)); err == nil && info.Size() > 0 {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(dataDir, legacyConfigName))
+	return err == nil
+}
+
+// MigrateLegacyDataDir moves the block log and chain.json of a legacy data directory to its
+// legacy/ subdirectory, so the directory can be initialized from a genesis file. The legacy
+// chain cannot be carried over: its genesis block was signed with a throwaway key and its
+// validators were registered at run time, so no genesis file describes it. Uploaded files
+// stay in the file store. It returns the archive directory.
+func MigrateLegacyDataDir(dataDir string) (string, error) {
+	if !IsLegacyDataDir(dataDir) {
+		return "", fmt.Errorf("%s is not a legacy data directory", dataDir)
+	}
+	archive := filepath.Join(dataDir, legacyDirName)
+	if _, err := os.Stat(archive); err == nil {
+		return "", fmt.Errorf("%s already exists", archive)
+	}
+	if err := os.Mkdir(archive, 0o755); err != nil {
+		return "", err
+	}
+
+	for _, name := range []string{blockLogName, legacyConfigName} {
+		err := os.Rename(filepath.Join(dataDir, name), filepath.Join(archive, name))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("archive %s: %w", name, err)
+		}
+	}
+	fmt.Printf("📦 Archived the legacy chain of %s to %s\n", dataDir, archive)
+	return archive, nil
+}
@@ -0,0 +1,382 @@
+package blockchain
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// keystoreDir is the directory of encrypted key files inside the data directory
+const keystoreDir = "keystore"
+
+// Key derivation and encryption parameters of key files; no other ones are read. The version is not a Web3 Secret
+// Storage version: key files use AES-GCM instead of AES-CTR with a MAC, so Web3 tools cannot read them.
+const (
+	keystoreVersion    = 100
+	keystoreCipher     = "aes-256-gcm"
+	keystoreKDF        = "pbkdf2"
+	keystorePRF        = "hmac-sha256"
+	keystoreIterations = 262144
+	keystoreKeyLen     = 32
+	keystoreSaltLen    = 32
+)
+
+var (
+	// ErrWalletNotFound is returned when no key file exists for a wallet ID
+	ErrWalletNotFound = errors.New("wallet not found")
+	// ErrWalletLocked is returned when signing with a wallet that has not been unlocked
+	ErrWalletLocked = errors.New("wallet is locked")
+	// ErrWrongPassword is returned when a key file cannot be decrypted with the given password
+	ErrWrongPassword = errors.New("wrong password")
+)
+
+// KeyInfo is the public description of a stored wallet
+type KeyInfo struct {
+	ID       string  `json:"id"`       // Wallet ID (its checksummed address)
+	Address  string  `json:"address"`  // Address derived from the public key
+	KeyType  KeyType `json:"key_type"` // Signature scheme of the key
+	Created  string  `json:"created"`  // When the key file was written
+	Unlocked bool    `json:"unlocked"` // Whether the key is currently usable for signing
+}
+
+// keyFile is the JSON keystore format. It follows the Web3 Secret Storage layout (cipher,
+// ciphertext, cipherparams, kdf, kdfparams) under its own version number. The AES-GCM tag
+// authenticates the ciphertext, so no separate MAC is stored.
+type keyFile struct {
+	Version int        `json:"version"`
+	ID      string     `json:"id"`
+	Address string     `json:"address"`
+	Created string     `json:"created"`
+	Crypto  cryptoJSON `json:"crypto"`
+}
+
+type cryptoJSON struct {
+	Cipher       string       `json:"cipher"`
+	CipherText   string       `json:"ciphertext"`
+	CipherParams cipherParams `json:"cipherparams"`
+	KDF          string       `json:"kdf"`
+	KDFParams    kdfParams    `json:"kdfparams"`
+}
+
+type cipherParams struct {
+	Nonce string `json:"nonce"`
+}
+
+type kdfParams struct {
+	C     int    `json:"c"`
+	DKLen int    `json:"dklen"`
+	PRF   string `json:"prf"`
+	Salt  string `json:"salt"`
+}
+
+// Keystore stores wallet keys encrypted at rest and keeps unlocked wallets in memory
+type Keystore struct {
+	dir      string
+	unlocked map[string]*Wallet
+	mu       sync.Mutex
+}
+
+// OpenKeystore opens (or creates) the keystore in dataDir
+func OpenKeystore(dataDir string) (*Keystore, error) {
+	dir := filepath.Join(dataDir, keystoreDir)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create keystore: %w", err)
+	}
+	return &Keystore{dir: dir, unlocked: make(map[string]*Wallet)}, nil
+}
+
+// Create generates a new wallet of the given key type and stores it encrypted with the password
+func (ks *Keystore) Create(keyType KeyType, password string) (KeyInfo, error) {
+	wallet, err := NewWalletOfType(keyType)
+	if err != nil {
+		return KeyInfo{}, err
+	}
+	return ks.store(wallet, password)
+}
+
+// Import stores an existing hex-encoded private key (PKCS#8 DER, or SEC 1 DER for P-256) encrypted with the password
+func (ks *Keystore) Import(privateKeyHex string, password string) (KeyInfo, error) {
+	der, err := hex.DecodeString(strings.TrimSpace(privateKeyHex))
+	if err != nil {
+		return KeyInfo{}, fmt.Errorf("private key is not hex: %w", err)
+	}
+	signer, err := parsePrivateKey(der)
+	if err != nil {
+		return KeyInfo{}, err
+	}
+	return ks.store(&Wallet{Signer: signer}, password)
+}
+
+// ImportKeyFile stores a key file exported by another keystore after checking its password
+func (ks *Keystore) ImportKeyFile(data []byte, password string) (KeyInfo, error) {
+	var file keyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return KeyInfo{}, fmt.Errorf("decode key file: %w", err)
+	}
+	wallet, err := decryptKeyFile(file, password)
+	if err != nil {
+		return KeyInfo{}, err
+	}
+	return ks.store(wallet, password)
+}
+
+// Export returns the encrypted key file of a wallet after checking its password
+func (ks *Keystore) Export(id string, password string) ([]byte, error) {
+	file, err := ks.readKeyFile(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := decryptKeyFile(file, password); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(file, "", "  ")
+}
+
+// List describes every stored wallet, sorted by ID
+func (ks *Keystore) List() ([]KeyInfo, error) {
+	entries, err := os.ReadDir(ks.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	infos := []KeyInfo{}
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok {
+			continue
+		}
+		file, err := ks.readKeyFile(id)
+		if err != nil {
+			fmt.Printf("⚠ Skipping unreadable key file %s: %v\n", entry.Name(), err)
+			continue
+		}
+		_, unlocked := ks.unlocked[id]
+		keyType, _, _ := ParseAddress(file.Address)
+		infos = append(infos, KeyInfo{ID: file.ID, Address: file.Address, KeyType: keyType, Created: file.Created, Unlocked: unlocked})
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos, nil
+}
+
+// Unlock decrypts a wallet and keeps it in memory for signing
+func (ks *Keystore) Unlock(id string, password string) error {
+	file, err := ks.readKeyFile(id)
+	if err != nil {
+		return err
+	}
+	wallet, err := decryptKeyFile(file, password)
+	if err != nil {
+		return err
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.unlocked[id] = wallet
+	fmt.Println("🔓 Wallet unlocked:", id)
+	return nil
+}
+
+// Lock forgets the decrypted key of a wallet
+func (ks *Keystore) Lock(id string) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	delete(ks.unlocked, id)
+	fmt.Println("🔒 Wallet locked:", id)
+}
+
+// Wallet returns an unlocked wallet by ID
+func (ks *Keystore) Wallet(id string) (*Wallet, error) {
+	if err := ValidateAddress(id); err != nil {
+		return nil, err
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if wallet, ok := ks.unlocked[id]; ok {
+		return wallet, nil
+	}
+	if _, err := os.Stat(ks.keyPath(id)); os.IsNotExist(err) {
+		return nil, ErrWalletNotFound
+	}
+	return nil, ErrWalletLocked
+}
+
+// store encrypts a wallet's private key and writes its key file
+func (ks *Keystore) store(wallet *Wallet, password string) (KeyInfo, error) {
+	if password == "" {
+		return KeyInfo{}, errors.New("password must not be empty")
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(wallet.Signer.PrivateKey())
+	if err != nil {
+		return KeyInfo{}, err
+	}
+
+	salt := make([]byte, keystoreSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return KeyInfo{}, err
+	}
+	key := pbkdf2.Key([]byte(password), salt, keystoreIterations, keystoreKeyLen, sha256.New)
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return KeyInfo{}, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return KeyInfo{}, err
+	}
+
+	address := wallet.Address()
+	file := keyFile{
+		Version: keystoreVersion,
+		ID:      address,
+		Address: address,
+		Created: time.Now().UTC().Format(time.RFC3339),
+		Crypto: cryptoJSON{
+			Cipher:       keystoreCipher,
+			CipherText:   hex.EncodeToString(gcm.Seal(nil, nonce, der, []byte(address))),
+			CipherParams: cipherParams{Nonce: hex.EncodeToString(nonce)},
+			KDF:          keystoreKDF,
+			KDFParams: kdfParams{
+				C:     keystoreIterations,
+				DKLen: keystoreKeyLen,
+				PRF:   keystorePRF,
+				Salt:  hex.EncodeToString(salt),
+			},
+		},
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return KeyInfo{}, err
+	}
+
+	// ✅ Write atomically so a crash never leaves a half-written key file
+	path := ks.keyPath(file.ID)
+	if _, err := os.Stat(path); err == nil {
+		return KeyInfo{}, fmt.Errorf("wallet %s already exists", file.ID)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return KeyInfo{}, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return KeyInfo{}, err
+	}
+
+	fmt.Println("🔑 Wallet stored in keystore:", file.ID)
+	return KeyInfo{ID: file.ID, Address: file.Address, KeyType: wallet.KeyType(), Created: file.Created}, nil
+}
+
+func (ks *Keystore) keyPath(id string) string {
+	return filepath.Join(ks.dir, filepath.Base(id)+".json")
+}
+
+func (ks *Keystore) readKeyFile(id string) (keyFile, error) {
+	var file keyFile
+	if err := ValidateAddress(id); err != nil {
+		return file, err
+	}
+	data, err := os.ReadFile(ks.keyPath(id))
+	if os.IsNotExist(err) {
+		return file, ErrWalletNotFound
+	}
+	if err != nil {
+		return file, err
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return file, fmt.Errorf("decode key file: %w", err)
+	}
+	return file, nil
+}
+
+// decryptKeyFile derives the key from the password and decrypts the wallet
+func decryptKeyFile(file keyFile, password string) (*Wallet, error) {
+	if file.Version != keystoreVersion || file.Crypto.Cipher != keystoreCipher ||
+		file.Crypto.KDF != keystoreKDF || file.Crypto.KDFParams.PRF != keystorePRF {
+		return nil, errors.New("unsupported key file format")
+	}
+
+	params := file.Crypto.KDFParams
+	salt, err := hex.DecodeString(params.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt: %w", err)
+	}
+	nonce, err := hex.DecodeString(file.Crypto.CipherParams.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nonce: %w", err)
+	}
+	cipherText, err := hex.DecodeString(file.Crypto.CipherText)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ciphertext: %w", err)
+	}
+	// ✅ The iteration count comes from the file, so only ours is accepted: a huge one would
+	// keep the node busy deriving the key
+	if params.C != keystoreIterations || params.DKLen != keystoreKeyLen {
+		return nil, errors.New("invalid key derivation parameters")
+	}
+
+	gcm, err := newGCM(pbkdf2.Key([]byte(password), salt, params.C, params.DKLen, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, errors.New("invalid nonce length")
+	}
+	der, err := gcm.Open(nil, nonce, cipherText, []byte(file.Address))
+	if err != nil {
+		return nil, ErrWrongPassword
+	}
+
+	signer, err := parsePrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	wallet := &Wallet{Signer: signer}
+	if wallet.Address() != file.Address {
+		return nil, errors.New("key file address does not match its key")
+	}
+	return wallet, nil
+}
+
+// parsePrivateKey decodes a PKCS#8 (P-256 or Ed25519) or SEC 1 DER (P-256) private key
+func parsePrivateKey(der []byte) (Signer, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return NewSigner(key)
+	}
+	privateKey, err := x509.ParseECPrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	return NewSigner(privateKey)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
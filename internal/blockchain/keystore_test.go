@@ -0,0 +1,142 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestKeystoreUnlock(t *testing.T) {
+	source, err := OpenKeystore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := source.Create(KeyTypeEd25519, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, err := source.readKeyFile(info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		edit     func(file *keyFile)
+		wantErr  error // Error the unlock must wrap; any error if nil
+		wantFail bool
+	}{
+		{name: "right password", password: "secret"},
+		{name: "wrong password", password: "guess", wantErr: ErrWrongPassword, wantFail: true},
+		{name: "empty password", password: "", wantErr: ErrWrongPassword, wantFail: true},
+		{
+			name:     "huge iteration count",
+			password: "secret",
+			edit:     func(file *keyFile) { file.Crypto.KDFParams.C = 1 << 30 },
+			wantFail: true,
+		},
+		{
+			name:     "other address",
+			password: "secret",
+			edit: func(file *keyFile) {
+				wallet, _ := NewWalletOfType(KeyTypeEd25519)
+				file.Address = wallet.Address()
+			},
+			wantErr:  ErrWrongPassword, // ✅ The address is authenticated with the ciphertext
+			wantFail: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks, err := OpenKeystore(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			file := original
+			if tt.edit != nil {
+				tt.edit(&file)
+			}
+			data, err := json.Marshal(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(ks.keyPath(info.ID), data, 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			err = ks.Unlock(info.ID, tt.password)
+			if tt.wantFail {
+				if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+					t.Fatalf("unlock returned %v, expected %v", err, tt.wantErr)
+				}
+				if _, err := ks.Wallet(info.ID); !errors.Is(err, ErrWalletLocked) {
+					t.Fatalf("wallet after a failed unlock: %v, expected it locked", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unlock: %v", err)
+			}
+
+			// ✅ The decrypted key is the one that was stored
+			wallet, err := ks.Wallet(info.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if wallet.Address() != info.Address {
+				t.Fatalf("unlocked %s, expected %s", wallet.Address(), info.Address)
+			}
+			signature, err := wallet.SignMessage("hello")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !VerifyMessage(wallet.PublicKey(), "hello", signature) {
+				t.Fatal("message signed by the unlocked wallet does not verify")
+			}
+		})
+	}
+}
+
+func TestKeystoreExportImport(t *testing.T) {
+	source, err := OpenKeystore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, keyType := range []KeyType{KeyTypeP256, KeyTypeEd25519} {
+		t.Run(string(keyType), func(t *testing.T) {
+			info, err := source.Create(keyType, "secret")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := source.Export(info.ID, "guess"); !errors.Is(err, ErrWrongPassword) {
+				t.Fatalf("export with a wrong password: %v", err)
+			}
+			data, err := source.Export(info.ID, "secret")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			target, err := OpenKeystore(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := target.ImportKeyFile(data, "guess"); !errors.Is(err, ErrWrongPassword) {
+				t.Fatalf("import with a wrong password: %v", err)
+			}
+			imported, err := target.ImportKeyFile(data, "secret")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if imported.ID != info.ID || imported.KeyType != keyType {
+				t.Fatalf("imported %s (%s), expected %s (%s)", imported.ID, imported.KeyType, info.ID, keyType)
+			}
+			if err := target.Unlock(info.ID, "secret"); err != nil {
+				t.Fatalf("unlock the imported key: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInsufficientBalance is returned for a transfer the sender's balance does not cover
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
+// Account is the QRY balance of an address and the number of transactions it has signed
+type Account struct {
+	Address string `json:"address"`
+	Balance int64  `json:"balance"`
+	Bonded  int64  `json:"bonded"` // QRY staked by a validator, not spendable
+	Nonce   uint64 `json:"nonce"`  // Transactions signed; the next one must use Nonce+1
+}
+
+// Ledger holds the QRY accounts of a State. It is derived purely by replaying blocks: the
+// genesis allocations, then per block its coinbase, transfers, stake changes and slashing, so
+// every node holding the same chain computes identical balances.
+type Ledger struct {
+	accounts map[string]*Account
+}
+
+// NewLedger returns the ledger before the first block: the genesis allocations
+func NewLedger(genesis *Genesis) *Ledger {
+	ledger := &Ledger{accounts: make(map[string]*Account)}
+	if genesis != nil {
+		for _, allocation := range genesis.Allocations {
+			ledger.account(allocation.Address).Balance += allocation.Amount
+		}
+	}
+	return ledger
+}
+
+// Account returns the account of an address (empty if it never received QRY)
+func (l *Ledger) Account(address string) Account {
+	if account, ok := l.accounts[address]; ok {
+		return *account
+	}
+	return Account{Address: address}
+}
+
+func (l *Ledger) account(address string) *Account {
+	account, ok := l.accounts[address]
+	if !ok {
+		account = &Account{Address: address}
+		l.accounts[address] = account
+	}
+	return account
+}
+
+// mint credits newly created QRY (block rewards)
+func (l *Ledger) mint(payouts []Payout) {
+	for _, payout := range payouts {
+		l.account(payout.Address).Balance += payout.Amount
+	}
+}
+
+// checkNonce checks that a transaction is the sender's next one
+func (l *Ledger) checkNonce(sender Sender) error {
+	if expected := l.Account(sender.From).Nonce + 1; sender.Nonce != expected {
+		return fmt.Errorf("nonce %d, account %s expects %d", sender.Nonce, sender.From, expected)
+	}
+	return nil
+}
+
+// useNonce counts a transaction signed by an address
+func (l *Ledger) useNonce(address string) {
+	l.account(address).Nonce++
+}
+
+// transfer applies a transfer whose signature was checked; the ledger is unchanged if it fails
+func (l *Ledger) transfer(t Transfer) error {
+	if err := l.checkNonce(t.Sender); err != nil {
+		return err
+	}
+	if balance := l.Account(t.From).Balance; t.Amount > balance {
+		return fmt.Errorf("%w: %s holds %d QRY, transfer is %d", ErrInsufficientBalance, t.From, balance, t.Amount)
+	}
+
+	l.useNonce(t.From)
+	l.account(t.From).Balance -= t.Amount
+	l.account(t.To).Balance += t.Amount
+	return nil
+}
+
+// bond moves QRY from the sender's balance to its bonded stake; the ledger is unchanged if it fails
+func (l *Ledger) bond(sender Sender, amount int64) error {
+	if err := l.checkNonce(sender); err != nil {
+		return err
+	}
+	if balance := l.Account(sender.From).Balance; amount > balance {
+		return fmt.Errorf("%w: %s holds %d QRY, stake is %d", ErrInsufficientBalance, sender.From, balance, amount)
+	}
+
+	l.useNonce(sender.From)
+	account := l.account(sender.From)
+	account.Balance -= amount
+	account.Bonded += amount
+	return nil
+}
+
+// unbond moves bonded QRY back to the sender's balance; the ledger is unchanged if it fails
+func (l *Ledger) unbond(sender Sender, amount int64) error {
+	if err := l.checkNonce(sender); err != nil {
+		return err
+	}
+	if bonded := l.Account(sender.From).Bonded; amount > bonded {
+		return fmt.Errorf("%w: %s has %d QRY bonded, unstake is %d", ErrInsufficientBond, sender.From, bonded, amount)
+	}
+
+	l.useNonce(sender.From)
+	account := l.account(sender.From)
+	account.Bonded -= amount
+	account.Balance += amount
+	return nil
+}
+
+// slash deducts up to amount from an address's bonded stake if bond is set, then from its
+// balance (never below zero), and returns what was deducted
+func (l *Ledger) slash(address string, amount int64, bond bool) int64 {
+	account := l.account(address)
+	fromBond := int64(0)
+	if bond {
+		fromBond = min(amount, account.Bonded)
+	}
+	fromBalance := min(amount-fromBond, account.Balance)
+	account.Bonded -= fromBond
+	account.Balance -= fromBalance
+	return fromBond + fromBalance
+}
+
+// ============================
+// Transfers and coinbase
+// ============================
+
+// Transfer moves QRY from the sender's account to another address
+type Transfer struct {
+	Sender
+	To     string // Recipient address
+	Amount int64  // QRY moved, positive
+}
+
+// NewTransferTransaction signs a transfer paying fee with the sender's wallet and wraps it in a transaction
+func NewTransferTransaction(wallet *Wallet, to string, amount int64, fee int64, nonce uint64) (Transaction, error) {
+	tx := Transaction{Type: TxTransfer, Fee: fee, Transfer: &Transfer{To: to, Amount: amount}}
+	if err := SignTransaction(wallet, &tx, nonce); err != nil {
+		return Transaction{}, err
+	}
+	return tx, nil
+}
+
+// NewSignedTransferTransaction wraps a transfer paying fee, signed by the sender, in a transaction
+func NewSignedTransferTransaction(transfer Transfer, fee int64) Transaction {
+	return NewSignedTransaction(Transaction{Type: TxTransfer, Fee: fee, Transfer: &transfer})
+}
+
+// Payout is QRY minted to an address by a block's coinbase
+type Payout struct {
+	Address string
+	Amount  int64
+}
+
+// Coinbase mints the rewards of a block; it is the block's first transaction
+type Coinbase struct {
+	Height  int      // Height of the block, so every coinbase has its own TxID
+	Payouts []Payout // As returned by ConsensusEngine.Rewards
+}
+
+// NewCoinbaseTransaction wraps the rewards of the block at height in a transaction
+func NewCoinbaseTransaction(height int, payouts []Payout) Transaction {
+	tx := Transaction{Type: TxCoinbase, Coinbase: &Coinbase{Height: height, Payouts: payouts}}
+	tx.TxID = tx.calculateTxID()
+	return tx
+}
+
+// samePayouts reports whether two payout lists are identical
+func samePayouts(a, b []Payout) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -1,45 +1,415 @@
 package blockchain
 
 import (
+	"container/list"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 )
 
-// Mempool stores pending transactions before they are mined
+// Default mempool limits
+const (
+	DefaultMempoolMaxTransactions = 10000         // Pending transactions
+	DefaultMempoolMaxBytes        = 16 << 20      // Encoded size of the pending transactions (see Transaction.Size)
+	DefaultMempoolMaxPerUploader  = 64            // Pending transactions paid for by one uploader or sender
+	DefaultMempoolTTL             = 1 * time.Hour // Time a transaction may wait for a block
+	DefaultMinFeeBump             = 10            // Percent a replacement must add to the fee
+)
+
+// Mempool errors
+var (
+	ErrMempoolFull            = errors.New("mempool is full")
+	ErrUploaderLimit          = errors.New("too many pending transactions")
+	ErrReplacementUnderpriced = errors.New("replacement fee too low")
+)
+
+// Mempool stores pending transactions before they are mined. It is bounded: when it is full,
+// a transaction paying a higher fee per byte evicts the lowest paying ones. Transactions
+// expire after TTL, and one paying a higher fee replaces the pending transaction it conflicts
+// with (the same sender nonce, or the same file). OnDrop learns of every transaction that
+// leaves it without being mined.
 type Mempool struct {
-	Transactions []Transaction // List of transactions in mempool
-	mu          sync.Mutex     // Mutex to prevent concurrent modification issues
+	MaxTransactions int           // Pending transactions at most
+	MaxBytes        int           // Encoded bytes of pending transactions at most
+	MaxPerUploader  int           // Pending transactions per payer (uploader or sender) at most
+	TTL             time.Duration // Time after which a pending transaction is dropped; 0 keeps them
+	MinFeeBump      int           // Percent a replacement must add to the replaced fee (at least 1 QRY)
+
+	// OnDrop is called with a transaction that was evicted, expired or replaced, or that
+	// conflicts with a mined one. It runs with the mempool locked, so it must not use it.
+	OnDrop func(tx Transaction)
+
+	entries  *list.List               // Pending *mempoolEntry in arrival order
+	byID     map[string]*mempoolEntry // By TxID
+	byFile   map[string]*mempoolEntry // File notarizations by file hash
+	bySender map[string]*mempoolEntry // Account transactions by sender and nonce (see senderKey)
+	byPayer  map[string]int           // Number of pending transactions per payer
+	bytes    int                      // Encoded size of the pending transactions
+	mu       sync.Mutex               // Mutex to prevent concurrent modification issues
+}
+
+// mempoolEntry is a pending transaction with what the mempool ranks it by
+type mempoolEntry struct {
+	tx    Transaction
+	size  int       // Transaction.Size
+	rate  float64   // Transaction.FeePerByte
+	added time.Time // Arrival; the transaction expires TTL after it
+	elem  *list.Element
 }
 
-// NewMempool initializes an empty transaction mempool
+// NewMempool initializes an empty transaction mempool with the default limits
 func NewMempool() *Mempool {
 	return &Mempool{
-		Transactions: []Transaction{},
+		MaxTransactions: DefaultMempoolMaxTransactions,
+		MaxBytes:        DefaultMempoolMaxBytes,
+		MaxPerUploader:  DefaultMempoolMaxPerUploader,
+		TTL:             DefaultMempoolTTL,
+		MinFeeBump:      DefaultMinFeeBump,
+		entries:         list.New(),
+		byID:            make(map[string]*mempoolEntry),
+		byFile:          make(map[string]*mempoolEntry),
+		bySender:        make(map[string]*mempoolEntry),
+		byPayer:         make(map[string]int),
 	}
 }
 
-// AddTransaction adds a new transaction to the mempool
-func (m *Mempool) AddTransaction(tx Transaction) {
+// senderKey identifies the transaction of a sender with a nonce; only one can ever apply
+func senderKey(sender *Sender) string {
+	return fmt.Sprintf("%s:%d", sender.From, sender.Nonce)
+}
+
+// AddTransaction adds a new transaction to the mempool, replacing the pending transaction it
+// conflicts with and evicting lower paying ones if the mempool is full. It fails with
+// ErrReplacementUnderpriced, ErrUploaderLimit or ErrMempoolFull.
+func (m *Mempool) AddTransaction(tx Transaction) error {
 	m.mu.Lock()         // Lock to prevent race conditions
 	defer m.mu.Unlock() // Unlock after function execution
 
-	m.Transactions = append(m.Transactions, tx)
+	m.expire(time.Now())
+	if _, ok := m.byID[tx.TxID]; ok {
+		return fmt.Errorf("transaction %s is already pending", tx.TxID)
+	}
+	entry := &mempoolEntry{tx: tx, size: tx.Size(), rate: tx.FeePerByte(), added: time.Now()}
+
+	replaced := m.conflict(tx)
+	if replaced != nil {
+		if err := m.checkReplacement(replaced.tx, tx); err != nil {
+			return err
+		}
+	}
+	if payer := tx.Payer(); replaced == nil && payer != "" && m.MaxPerUploader > 0 && m.byPayer[payer] >= m.MaxPerUploader {
+		return fmt.Errorf("%w: %s has %d pending, the limit is %d", ErrUploaderLimit, payer, m.byPayer[payer], m.MaxPerUploader)
+	}
+	evicted, err := m.evictionsFor(entry, replaced)
+	if err != nil {
+		return err
+	}
+
+	if replaced != nil {
+		m.drop(replaced)
+		fmt.Printf("🔁 Transaction %s replaced by %s (fee %d → %d QRY)\n", replaced.tx.TxID, tx.TxID, replaced.tx.Fee, tx.Fee)
+	}
+	for _, victim := range evicted {
+		m.drop(victim)
+		fmt.Printf("🗑 Transaction %s evicted from the full mempool\n", victim.tx.TxID)
+	}
+	m.insert(entry)
 	fmt.Printf("✅ Transaction added to mempool: %s\n", tx.TxID)
+	return nil
+}
+
+// Conflict returns the pending transaction that tx would replace: the sender's transaction
+// with the same nonce, or the notarization of the same file
+func (m *Mempool) Conflict(tx Transaction) (Transaction, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if entry := m.conflict(tx); entry != nil {
+		return entry.tx, true
+	}
+	return Transaction{}, false
+}
+
+func (m *Mempool) conflict(tx Transaction) *mempoolEntry {
+	if sender := tx.Sender(); sender != nil {
+		return m.bySender[senderKey(sender)]
+	}
+	if tx.isFile() {
+		return m.byFile[tx.File.FileHash]
+	}
+	return nil
+}
+
+// CheckReplacement checks that tx may replace the pending transaction it conflicts with: it
+// has the same payer and pays at least ReplacementFee
+func (m *Mempool) CheckReplacement(pending Transaction, tx Transaction) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.checkReplacement(pending, tx)
+}
+
+func (m *Mempool) checkReplacement(pending Transaction, tx Transaction) error {
+	if pending.Payer() != tx.Payer() {
+		return fmt.Errorf("file %s is already pending in transaction %s", tx.File.FileHash, pending.TxID)
+	}
+	if minimum := m.replacementFee(pending); tx.Fee < minimum {
+		return fmt.Errorf("%w: transaction %s is pending in its place with a fee of %d QRY, a replacement must pay at least %d",
+			ErrReplacementUnderpriced, pending.TxID, pending.Fee, minimum)
+	}
+	return nil
 }
 
-// GetTransactions returns all transactions in the mempool
+// ReplacementFee returns the least fee a transaction replacing a pending one must pay
+func (m *Mempool) ReplacementFee(pending Transaction) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.replacementFee(pending)
+}
+
+func (m *Mempool) replacementFee(pending Transaction) int64 {
+	bump := pending.Fee * int64(m.MinFeeBump) / 100
+	if bump < 1 {
+		bump = 1
+	}
+	return pending.Fee + bump
+}
+
+// evictionsFor returns the pending transactions to evict so entry fits next to the others
+// (replaced leaves anyway), lowest fee per byte first and the latest arrival first among
+// equals. Only transactions paying less per byte than entry are evicted, never evidence nor
+// the payer's own; the later nonces of an evicted sender go with it, as they cannot apply.
+func (m *Mempool) evictionsFor(entry *mempoolEntry, replaced *mempoolEntry) ([]*mempoolEntry, error) {
+	count, bytes := m.entries.Len()+1, m.bytes+entry.size
+	if replaced != nil {
+		count, bytes = count-1, bytes-replaced.size
+	}
+	fits := func() bool {
+		return (m.MaxTransactions <= 0 || count <= m.MaxTransactions) && (m.MaxBytes <= 0 || bytes <= m.MaxBytes)
+	}
+	if fits() {
+		return nil, nil
+	}
+
+	payer := entry.tx.Payer()
+	var candidates []*mempoolEntry
+	for elem := m.entries.Back(); elem != nil; elem = elem.Prev() {
+		if e := elem.Value.(*mempoolEntry); e != replaced && e.tx.Payer() != "" && e.tx.Payer() != payer {
+			candidates = append(candidates, e)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].rate < candidates[j].rate })
+
+	var evicted []*mempoolEntry
+	chosen := make(map[*mempoolEntry]bool)
+	for _, victim := range candidates {
+		if fits() {
+			break
+		}
+		if chosen[victim] {
+			continue
+		}
+		if payer != "" && victim.rate >= entry.rate {
+			break
+		}
+		for _, e := range append([]*mempoolEntry{victim}, m.dependents(victim)...) {
+			if !chosen[e] {
+				chosen[e] = true
+				evicted = append(evicted, e)
+				count, bytes = count-1, bytes-e.size
+			}
+		}
+	}
+	if !fits() {
+		return nil, fmt.Errorf("%w: a transaction must pay more than %.3f QRY per byte to enter", ErrMempoolFull, m.lowestRate())
+	}
+	return evicted, nil
+}
+
+// dependents returns the sender's pending transactions with the nonces after entry's, which
+// cannot apply without it
+func (m *Mempool) dependents(entry *mempoolEntry) []*mempoolEntry {
+	sender := entry.tx.Sender()
+	if sender == nil {
+		return nil
+	}
+	var dependents []*mempoolEntry
+	for nonce := sender.Nonce + 1; ; nonce++ {
+		next, ok := m.bySender[senderKey(&Sender{From: sender.From, Nonce: nonce})]
+		if !ok {
+			return dependents
+		}
+		dependents = append(dependents, next)
+	}
+}
+
+// lowestRate returns the lowest fee per byte of the pending transactions
+func (m *Mempool) lowestRate() float64 {
+	lowest := 0.0
+	for elem := m.entries.Front(); elem != nil; elem = elem.Next() {
+		if e := elem.Value.(*mempoolEntry); elem == m.entries.Front() || e.rate < lowest {
+			lowest = e.rate
+		}
+	}
+	return lowest
+}
+
+// insert indexes a new entry
+func (m *Mempool) insert(entry *mempoolEntry) {
+	tx := entry.tx
+	entry.elem = m.entries.PushBack(entry)
+	m.byID[tx.TxID] = entry
+	if sender := tx.Sender(); sender != nil {
+		m.bySender[senderKey(sender)] = entry
+	} else if tx.isFile() {
+		m.byFile[tx.File.FileHash] = entry
+	}
+	if payer := tx.Payer(); payer != "" {
+		m.byPayer[payer]++
+	}
+	m.bytes += entry.size
+}
+
+// remove drops an entry and its indexes
+func (m *Mempool) remove(entry *mempoolEntry) {
+	tx := entry.tx
+	m.entries.Remove(entry.elem)
+	delete(m.byID, tx.TxID)
+	if sender := tx.Sender(); sender != nil && m.bySender[senderKey(sender)] == entry {
+		delete(m.bySender, senderKey(sender))
+	} else if tx.isFile() && m.byFile[tx.File.FileHash] == entry {
+		delete(m.byFile, tx.File.FileHash)
+	}
+	if payer := tx.Payer(); payer != "" {
+		if m.byPayer[payer]--; m.byPayer[payer] <= 0 {
+			delete(m.byPayer, payer)
+		}
+	}
+	m.bytes -= entry.size
+}
+
+// drop removes an entry that leaves the mempool without being mined and reports it to OnDrop
+func (m *Mempool) drop(entry *mempoolEntry) {
+	m.remove(entry)
+	if m.OnDrop != nil {
+		m.OnDrop(entry.tx)
+	}
+}
+
+// expire drops the transactions that waited longer than TTL, and the later nonces of their senders
+func (m *Mempool) expire(now time.Time) {
+	if m.TTL <= 0 {
+		return
+	}
+	// ✅ Entries arrive in order, so the expired ones are at the front
+	var expired []*mempoolEntry
+	for elem := m.entries.Front(); elem != nil; elem = elem.Next() {
+		e := elem.Value.(*mempoolEntry)
+		if now.Sub(e.added) <= m.TTL {
+			break
+		}
+		expired = append(expired, e)
+	}
+	for _, e := range expired {
+		if _, ok := m.byID[e.tx.TxID]; !ok {
+			continue // ✅ Already dropped as a dependent
+		}
+		dependents := m.dependents(e)
+		m.drop(e)
+		fmt.Printf("⌛ Transaction %s expired after waiting %s in the mempool\n", e.tx.TxID, m.TTL)
+		for _, dependent := range dependents {
+			m.drop(dependent)
+			fmt.Printf("🗑 Transaction %s dropped: it follows expired transaction %s\n", dependent.tx.TxID, e.tx.TxID)
+		}
+	}
+}
+
+// GetTransactions returns a copy of the pending transactions in arrival order
 func (m *Mempool) GetTransactions() []Transaction {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	return m.Transactions // Return a copy of transactions
+	m.expire(time.Now())
+	transactions := make([]Transaction, 0, m.entries.Len())
+	for elem := m.entries.Front(); elem != nil; elem = elem.Next() {
+		transactions = append(transactions, elem.Value.(*mempoolEntry).tx)
+	}
+	return transactions
 }
 
-// Clear clears the mempool after mining a block
-func (m *Mempool) Clear() {
+// ByPriority returns a copy of the pending transactions, highest fee per byte first;
+// transactions paying the same rate keep their arrival order
+func (m *Mempool) ByPriority() []Transaction {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.Transactions = []Transaction{} // Reset mempool
-	fmt.Println("✅ Mempool cleared after block mining.")
+	m.expire(time.Now())
+	entries := make([]*mempoolEntry, 0, m.entries.Len())
+	for elem := m.entries.Front(); elem != nil; elem = elem.Next() {
+		entries = append(entries, elem.Value.(*mempoolEntry))
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].rate > entries[j].rate })
+
+	transactions := make([]Transaction, len(entries))
+	for i, e := range entries {
+		transactions[i] = e.tx
+	}
+	return transactions
+}
+
+// Len returns the number of pending transactions
+func (m *Mempool) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.expire(time.Now())
+	return m.entries.Len()
+}
+
+// Has reports whether a transaction with the given TxID is pending
+func (m *Mempool) Has(txID string) bool {
+	_, ok := m.Get(txID)
+	return ok
+}
+
+// Get returns the pending transaction with the given TxID
+func (m *Mempool) Get(txID string) (Transaction, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if entry, ok := m.byID[txID]; ok {
+		return entry.tx, true
+	}
+	return Transaction{}, false
+}
+
+// ByFileHash returns the pending notarization of a file
+func (m *Mempool) ByFileHash(fileHash string) (Transaction, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if entry, ok := m.byFile[fileHash]; ok {
+		return entry.tx, true
+	}
+	return Transaction{}, false
+}
+
+// RemoveTransactions drops the given transactions once they are in a block, and the pending
+// transactions they conflict with, which can no longer apply (those are reported to OnDrop).
+// Transactions that arrived in the meantime stay.
+func (m *Mempool) RemoveTransactions(transactions []Transaction) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, tx := range transactions {
+		if entry, ok := m.byID[tx.TxID]; ok {
+			m.remove(entry)
+		}
+		if entry := m.conflict(tx); entry != nil {
+			m.drop(entry)
+		}
+	}
 }
@@ -0,0 +1,258 @@
+package blockchain
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testTransfer returns an unsigned transfer of one QRY; the mempool does not check signatures
+func testTransfer(from string, nonce uint64, fee int64) Transaction {
+	tx := Transaction{Type: TxTransfer, Fee: fee, Transfer: &Transfer{Sender: Sender{From: from, Nonce: nonce}, To: "to", Amount: 1}}
+	tx.TxID = tx.calculateTxID()
+	return tx
+}
+
+// testUpload returns an unsigned notarization of fileHash by wallet
+func testUpload(t *testing.T, wallet *Wallet, fileHash string, fee int64) Transaction {
+	t.Helper()
+	return NewTransaction(fileHash, wallet.PublicKey(), 1024, 90, 1, fee, "")
+}
+
+// testMempool returns an empty mempool that records the TxID of every dropped transaction
+func testMempool(dropped *[]string) *Mempool {
+	m := NewMempool()
+	m.OnDrop = func(tx Transaction) { *dropped = append(*dropped, tx.TxID) }
+	return m
+}
+
+// testTxIDs returns the TxID of each transaction
+func testTxIDs(transactions ...Transaction) []string {
+	ids := []string{}
+	for _, tx := range transactions {
+		ids = append(ids, tx.TxID)
+	}
+	return ids
+}
+
+func TestMempoolAddTransaction(t *testing.T) {
+	alice, err := NewWalletOfType(KeyTypeEd25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := NewWalletOfType(KeyTypeEd25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const fileHash = "05d4a5c1e4f4f1d5b3c2a1e0f9d8c7b6a5f4e3d2c1b0a9f8e7d6c5b4a3f2e1d0"
+
+	a1 := testTransfer("a", 1, 100)
+	a1Bumped := testTransfer("a", 1, 110)
+	a1Underpriced := testTransfer("a", 1, 109)
+	a2 := testTransfer("a", 2, 100)
+	b1, b2 := testTransfer("b", 1, 10), testTransfer("b", 2, 500)
+	c1, d1 := testTransfer("c", 1, 20), testTransfer("d", 1, 30)
+	small, smallBumped := testTransfer("e", 1, 5), testTransfer("e", 1, 6)
+	upload := testUpload(t, alice, fileHash, 50)
+
+	tests := []struct {
+		name        string
+		limits      func(m *Mempool)
+		pending     []Transaction
+		tx          Transaction
+		wantErr     string // Part of the rejection; empty when the transaction enters
+		wantPending []string
+		wantDropped []string
+	}{
+		{
+			name:        "replacement with the fee bumped",
+			pending:     []Transaction{a1},
+			tx:          a1Bumped,
+			wantPending: testTxIDs(a1Bumped),
+			wantDropped: testTxIDs(a1),
+		},
+		{
+			name:        "replacement underpriced",
+			pending:     []Transaction{a1},
+			tx:          a1Underpriced,
+			wantErr:     ErrReplacementUnderpriced.Error(),
+			wantPending: testTxIDs(a1),
+		},
+		{
+			name:        "small fee bumped by one QRY",
+			pending:     []Transaction{small},
+			tx:          smallBumped,
+			wantPending: testTxIDs(smallBumped),
+			wantDropped: testTxIDs(small),
+		},
+		{
+			name:        "same file from another uploader",
+			pending:     []Transaction{upload},
+			tx:          testUpload(t, bob, fileHash, 500),
+			wantErr:     "already pending",
+			wantPending: testTxIDs(upload),
+		},
+		{
+			name:        "full mempool evicts the lowest rate",
+			limits:      func(m *Mempool) { m.MaxTransactions = 2 },
+			pending:     []Transaction{b1, c1},
+			tx:          d1,
+			wantPending: testTxIDs(c1, d1),
+			wantDropped: testTxIDs(b1),
+		},
+		{
+			name:        "eviction takes the later nonces",
+			limits:      func(m *Mempool) { m.MaxTransactions = 3 },
+			pending:     []Transaction{b1, b2, c1},
+			tx:          d1,
+			wantPending: testTxIDs(c1, d1),
+			wantDropped: testTxIDs(b1, b2),
+		},
+		{
+			name:        "full mempool keeps higher rates",
+			limits:      func(m *Mempool) { m.MaxTransactions = 2 },
+			pending:     []Transaction{c1, d1},
+			tx:          b1,
+			wantErr:     ErrMempoolFull.Error(),
+			wantPending: testTxIDs(c1, d1),
+		},
+		{
+			name:        "payer limit",
+			limits:      func(m *Mempool) { m.MaxPerUploader = 1 },
+			pending:     []Transaction{a1},
+			tx:          a2,
+			wantErr:     ErrUploaderLimit.Error(),
+			wantPending: testTxIDs(a1),
+		},
+		{
+			name:        "replacement at the payer limit",
+			limits:      func(m *Mempool) { m.MaxPerUploader = 1 },
+			pending:     []Transaction{a1},
+			tx:          a1Bumped,
+			wantPending: testTxIDs(a1Bumped),
+			wantDropped: testTxIDs(a1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dropped []string
+			m := testMempool(&dropped)
+			if tt.limits != nil {
+				tt.limits(m)
+			}
+			for _, tx := range tt.pending {
+				if err := m.AddTransaction(tx); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := m.AddTransaction(tt.tx)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("add: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("add returned %v, expected %q", err, tt.wantErr)
+			}
+			if got := testTxIDs(m.GetTransactions()...); !slices.Equal(got, tt.wantPending) {
+				t.Fatalf("pending %v, expected %v", got, tt.wantPending)
+			}
+			if !slices.Equal(dropped, tt.wantDropped) {
+				t.Fatalf("dropped %v, expected %v", dropped, tt.wantDropped)
+			}
+		})
+	}
+}
+
+func TestMempoolExpire(t *testing.T) {
+	a1, a2, b1 := testTransfer("a", 1, 10), testTransfer("a", 2, 10), testTransfer("b", 1, 10)
+
+	tests := []struct {
+		name        string
+		ttl         time.Duration
+		age         map[string]time.Duration // How long ago a transaction arrived
+		wantPending []string
+		wantDropped []string
+	}{
+		{
+			name:        "within the TTL",
+			ttl:         time.Hour,
+			age:         map[string]time.Duration{a1.TxID: 59 * time.Minute},
+			wantPending: testTxIDs(a1, a2, b1),
+		},
+		{
+			name:        "expired with its later nonces",
+			ttl:         time.Hour,
+			age:         map[string]time.Duration{a1.TxID: 61 * time.Minute},
+			wantPending: testTxIDs(b1),
+			wantDropped: testTxIDs(a1, a2),
+		},
+		{
+			name:        "every nonce of a sender expired",
+			ttl:         time.Hour,
+			age:         map[string]time.Duration{a1.TxID: 2 * time.Hour, a2.TxID: 2 * time.Hour},
+			wantPending: testTxIDs(b1),
+			wantDropped: testTxIDs(a1, a2),
+		},
+		{
+			name:        "no TTL",
+			ttl:         0,
+			age:         map[string]time.Duration{a1.TxID: 24 * time.Hour, a2.TxID: 24 * time.Hour},
+			wantPending: testTxIDs(a1, a2, b1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dropped []string
+			m := testMempool(&dropped)
+			m.TTL = tt.ttl
+			for _, tx := range []Transaction{a1, a2, b1} {
+				if err := m.AddTransaction(tx); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for txID, age := range tt.age {
+				m.byID[txID].added = time.Now().Add(-age)
+			}
+
+			if got := testTxIDs(m.GetTransactions()...); !slices.Equal(got, tt.wantPending) {
+				t.Fatalf("pending %v, expected %v", got, tt.wantPending)
+			}
+			if !slices.Equal(dropped, tt.wantDropped) {
+				t.Fatalf("dropped %v, expected %v", dropped, tt.wantDropped)
+			}
+		})
+	}
+}
+
+func TestMempoolByPriority(t *testing.T) {
+	low, mid, high := testTransfer("a", 1, 10), testTransfer("b", 1, 50), testTransfer("c", 1, 90)
+	midLater := testTransfer("d", 1, 50)
+	free := testTransfer("e", 1, 0)
+
+	tests := []struct {
+		name    string
+		arrival []Transaction
+		want    []string
+	}{
+		{name: "highest fee per byte first", arrival: []Transaction{low, high, mid}, want: testTxIDs(high, mid, low)},
+		{name: "equal rates in arrival order", arrival: []Transaction{midLater, low, mid}, want: testTxIDs(midLater, mid, low)},
+		{name: "no fee last", arrival: []Transaction{free, low}, want: testTxIDs(low, free)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMempool()
+			for _, tx := range tt.arrival {
+				if err := m.AddTransaction(tx); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := testTxIDs(m.ByPriority()...); !slices.Equal(got, tt.want) {
+				t.Fatalf("priority order %v, expected %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// Domain separation prefixes so a leaf can never be passed off as an inner node
+const (
+	merkleLeafPrefix = 0x00
+	merkleNodePrefix = 0x01
+)
+
+// MerkleStep is one sibling hash on the path from a leaf to the Merkle root
+type MerkleStep struct {
+	Hash string `json:"hash"` // Sibling hash (hex)
+	Left bool   `json:"left"` // True if the sibling is the left operand
+}
+
+// MerkleProof proves that a transaction is included under a block's Merkle root
+type MerkleProof struct {
+	TxID   string       `json:"tx_id"`  // Transaction being proven
+	Index  int          `json:"index"`  // Position of the transaction in the block
+	Leaves int          `json:"leaves"` // Number of transactions in the block
+	Path   []MerkleStep `json:"path"`   // Sibling hashes from the leaf up to the root
+}
+
+// merkleLeaf hashes a transaction ID into a leaf: SHA-256(0x00 || TxID)
+func merkleLeaf(txID string) []byte {
+	hash := sha256.Sum256(append([]byte{merkleLeafPrefix}, txID...))
+	return hash[:]
+}
+
+// merkleNode hashes two children into their parent: SHA-256(0x01 || left || right)
+func merkleNode(left, right []byte) []byte {
+	input := make([]byte, 0, 1+len(left)+len(right))
+	input = append(input, merkleNodePrefix)
+	input = append(input, left...)
+	input = append(input, right...)
+	hash := sha256.Sum256(input)
+	return hash[:]
+}
+
+// merkleLevels builds every level of the tree, leaves first. A node without a
+// sibling is promoted unchanged to the next level (no duplication).
+func merkleLevels(transactions []Transaction) [][][]byte {
+	level := make([][]byte, len(transactions))
+	for i, tx := range transactions {
+		level[i] = merkleLeaf(tx.TxID)
+	}
+
+	levels := [][][]byte{level}
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, merkleNode(level[i], level[i+1]))
+		}
+		levels = append(levels, next)
+		level = next
+	}
+	return levels
+}
+
+// MerkleRoot computes the Merkle root over the TxIDs of all transactions.
+// A block without transactions has the root SHA-256("").
+func MerkleRoot(transactions []Transaction) string {
+	if len(transactions) == 0 {
+		empty := sha256.Sum256(nil)
+		return hex.EncodeToString(empty[:])
+	}
+
+	levels := merkleLevels(transactions)
+	return hex.EncodeToString(levels[len(levels)-1][0])
+}
+
+// NewMerkleProof builds an inclusion proof for the transaction with the given TxID
+func NewMerkleProof(transactions []Transaction, txID string) (*MerkleProof, error) {
+	index := -1
+	for i, tx := range transactions {
+		if tx.TxID == txID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("transaction %s not found", txID)
+	}
+
+	proof := &MerkleProof{TxID: txID, Index: index, Leaves: len(transactions)}
+	position := index
+	levels := merkleLevels(transactions)
+	for _, level := range levels[:len(levels)-1] {
+		sibling := position ^ 1
+		if sibling < len(level) {
+			proof.Path = append(proof.Path, MerkleStep{
+				Hash: hex.EncodeToString(level[sibling]),
+				Left: sibling < position,
+			})
+		}
+		position /= 2
+	}
+	return proof, nil
+}
+
+// VerifyMerkleProof checks that the proof links its TxID to the given Merkle root. The
+// side of each sibling is derived from Index and Leaves, so a path that does not match
+// the position it claims is rejected.
+func VerifyMerkleProof(root string, proof MerkleProof) bool {
+	if proof.Index < 0 || proof.Index >= proof.Leaves {
+		return false
+	}
+
+	hash := merkleLeaf(proof.TxID)
+	path := proof.Path
+	for position, width := proof.Index, proof.Leaves; width > 1; position, width = position/2, (width+1)/2 {
+		// ✅ A node without a sibling is promoted, so the level has no step
+		sibling := position ^ 1
+		if sibling >= width {
+			continue
+		}
+		if len(path) == 0 || path[0].Left != (sibling < position) {
+			return false
+		}
+		siblingHash, err := hex.DecodeString(path[0].Hash)
+		if err != nil || len(siblingHash) != sha256.Size {
+			return false
+		}
+		if path[0].Left {
+			hash = merkleNode(siblingHash, hash)
+		} else {
+			hash = merkleNode(hash, siblingHash)
+		}
+		path = path[1:]
+	}
+	return len(path) == 0 && hex.EncodeToString(hash) == root
+}
+
+// ProveTransaction builds an inclusion proof for one of the block's transactions
+func (b *Block) ProveTransaction(txID string) (*MerkleProof, error) {
+	return NewMerkleProof(b.Transactions, txID)
+}
@@ -0,0 +1,49 @@
+package blockchain
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestVerifyMerkleProof(t *testing.T) {
+	transactions := make([]Transaction, 5)
+	for i := range transactions {
+		transactions[i] = Transaction{TxID: fmt.Sprintf("tx%d", i)}
+	}
+	root := MerkleRoot(transactions)
+
+	tests := []struct {
+		name   string
+		txID   string
+		tamper func(proof *MerkleProof)
+		want   bool
+	}{
+		{name: "first transaction", txID: "tx0", want: true},
+		{name: "promoted last transaction", txID: "tx4", want: true},
+		{name: "other index", txID: "tx1", tamper: func(proof *MerkleProof) { proof.Index = 3 }},
+		{name: "index out of range", txID: "tx4", tamper: func(proof *MerkleProof) { proof.Index = 5 }},
+		{name: "negative index", txID: "tx0", tamper: func(proof *MerkleProof) { proof.Index = -1 }},
+		{name: "other leaf count", txID: "tx4", tamper: func(proof *MerkleProof) { proof.Leaves = 8 }},
+		{name: "flipped side", txID: "tx2", tamper: func(proof *MerkleProof) { proof.Path[0].Left = !proof.Path[0].Left }},
+		{name: "extra step", txID: "tx2", tamper: func(proof *MerkleProof) { proof.Path = append(proof.Path, proof.Path[0]) }},
+		{name: "missing step", txID: "tx2", tamper: func(proof *MerkleProof) { proof.Path = proof.Path[1:] }},
+		{name: "other transaction", txID: "tx2", tamper: func(proof *MerkleProof) { proof.TxID = "tx3" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proof, err := NewMerkleProof(transactions, tt.txID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.tamper != nil {
+				tt.tamper(proof)
+			} else if !tt.want {
+				t.Fatal("case without tampering must verify")
+			}
+			if got := VerifyMerkleProof(root, *proof); got != tt.want {
+				t.Fatalf("verify returned %v, expected %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,11 +1,12 @@
 package blockchain
 
 import (
-	"bufio"
-	"encoding/json"
+	"encoding/binary"
 	"fmt"
+	"io"
 	"net"
 	"strings"
+	"time"
 )
 
 // Peer-to-Peer Network
@@ -75,71 +76,258 @@ func getLocalIPv4() string {
 }
 
 
-// ConnectToPeer connects to a remote peer
-func (p2p *P2PNetwork) ConnectToPeer(address string) {
+// ConnectToPeer connects to a remote peer; peers of another network (different genesis) are refused
+func (p2p *P2PNetwork) ConnectToPeer(address string) error {
 	conn, err := net.Dial("tcp4", address) // Force IPv4
 	if err != nil {
 		fmt.Println("❌ Failed to connect to peer:", err)
-		return
+		return err
 	}
 	defer conn.Close()
 
+	if err := p2p.handshake(conn); err != nil {
+		fmt.Println("❌ Refusing peer", address+":", err)
+		return err
+	}
+
 	p2p.Peers = append(p2p.Peers, address)
 	fmt.Println("🔗 Connected to peer:", address)
 
 	// Send our blockchain to the new peer
 	p2p.SendBlockchain(conn)
+	return nil
 }
 
 // HandleConnection processes incoming peer connections
 func (p2p *P2PNetwork) HandleConnection(conn net.Conn) {
 	defer conn.Close()
-	reader := bufio.NewReader(conn)
-	data, err := reader.ReadString('\n')
+	if err := p2p.handshake(conn); err != nil {
+		fmt.Println("❌ Refusing peer", conn.RemoteAddr().String()+":", err)
+		return
+	}
+
+	payload, err := readFrame(conn, p2p.maxFrameSize())
 	if err != nil {
 		fmt.Println("❌ Error reading data:", err)
 		return
 	}
+	if len(payload) == 0 {
+		fmt.Println("❌ Received empty message from peer")
+		return
+	}
 
-	// Determine if we received a blockchain update
-	if strings.HasPrefix(data, "BLOCKCHAIN_UPDATE") {
-		var receivedChain Blockchain
-		err = json.Unmarshal([]byte(strings.TrimPrefix(data, "BLOCKCHAIN_UPDATE ")), &receivedChain)
+	// ✅ The tag of the canonical encoding identifies the message type
+	switch payload[0] {
+	case tagChain:
+		length, err := DecodeChainHeader(payload)
 		if err != nil {
 			fmt.Println("❌ Error decoding blockchain:", err)
 			return
 		}
+		receivedChain, err := p2p.readChain(conn, length)
+		if err != nil {
+			fmt.Println("❌ Error reading blockchain:", err)
+			return
+		}
+
+		// ✅ Fork choice decides whether the received chain becomes our head
+		fmt.Println("🔄 Synchronizing blockchain from peer...")
+		if err := p2p.Blockchain.ReceiveChain(receivedChain); err != nil {
+			fmt.Println("❌ Rejected blockchain from peer:", err)
+		}
+
+	case tagBlock:
+		block, err := DecodeBlock(payload)
+		if err != nil {
+			fmt.Println("❌ Error decoding block:", err)
+			return
+		}
+
+		if err := p2p.Blockchain.AcceptBlock(block); err != nil {
+			fmt.Printf("❌ Rejected block #%d from peer: %v\n", block.Index, err)
+			return
+		}
+		fmt.Printf("✅ Block #%d received from peer\n", block.Index)
+
+	case tagProposal:
+		round, block, err := DecodeProposal(payload)
+		if err != nil {
+			fmt.Println("❌ Error decoding proposal:", err)
+			return
+		}
+		if p2p.Blockchain.BFT == nil {
+			return // ✅ Nodes of engines without voting ignore proposals
+		}
+		if err := p2p.Blockchain.BFT.HandleProposal(round, block); err != nil {
+			fmt.Printf("⚠ Ignored proposal for Block #%d: %v\n", block.Index, err)
+		}
 
-		// Synchronize if the received chain is longer
-		if len(receivedChain.Chain) > len(p2p.Blockchain.Chain) {
-			fmt.Println("🔄 Synchronizing blockchain from peer...")
-			p2p.Blockchain.Chain = receivedChain.Chain
+	case tagVote:
+		vote, err := DecodeVote(payload)
+		if err != nil {
+			fmt.Println("❌ Error decoding vote:", err)
+			return
+		}
+		if p2p.Blockchain.BFT == nil {
+			return
 		}
+		if err := p2p.Blockchain.BFT.HandleVote(vote); err != nil {
+			fmt.Println("❌ Rejected vote from peer:", err)
+		}
+
+	default:
+		fmt.Printf("❌ Unknown message type %q from peer\n", payload[0])
+	}
+}
+
+// readChain reads the blocks following a chain header, one frame each
+func (p2p *P2PNetwork) readChain(r io.Reader, length int) ([]Block, error) {
+	var chain []Block // ✅ Grows with the blocks actually received, not the announced length
+	for i := 0; i < length; i++ {
+		payload, err := readFrame(r, p2p.maxFrameSize())
+		if err != nil {
+			return nil, err
+		}
+		block, err := DecodeBlock(payload)
+		if err != nil {
+			return nil, fmt.Errorf("block %d of %d: %w", i, length, err)
+		}
+		chain = append(chain, block)
+	}
+	return chain, nil
+}
+
+// chainFrames returns the messages carrying our blockchain: its length, then each block
+func (p2p *P2PNetwork) chainFrames() [][]byte {
+	chain := p2p.Blockchain.ChainSnapshot()
+	frames := [][]byte{EncodeChainHeader(len(chain))}
+	for _, block := range chain {
+		frames = append(frames, EncodeBlock(block))
 	}
+	return frames
 }
 
 // SendBlockchain sends our blockchain to a peer
 func (p2p *P2PNetwork) SendBlockchain(conn net.Conn) {
-	blockchainData, _ := json.Marshal(p2p.Blockchain)
-	conn.Write([]byte("BLOCKCHAIN_UPDATE " + string(blockchainData) + "\n"))
+	for _, frame := range p2p.chainFrames() {
+		if err := writeFrame(conn, frame, p2p.maxFrameSize()); err != nil {
+			fmt.Println("❌ Error sending blockchain:", err)
+			return
+		}
+	}
 }
+
 // BroadcastBlockchain sends the blockchain to all connected peers
 func (p2p *P2PNetwork) BroadcastBlockchain() {
-	blockchainData, err := json.Marshal(p2p.Blockchain)
-	if err != nil {
-		fmt.Println("❌ Error encoding blockchain:", err)
-		return
-	}
+	p2p.broadcast("blockchain update", p2p.chainFrames()...)
+}
 
+// BroadcastBlock sends a newly mined block to all connected peers
+func (p2p *P2PNetwork) BroadcastBlock(block Block) {
+	p2p.broadcast(fmt.Sprintf("block #%d", block.Index), EncodeBlock(block))
+}
+
+// BroadcastProposal sends a block proposed in a voting round to all connected peers
+func (p2p *P2PNetwork) BroadcastProposal(round int, block Block) {
+	p2p.broadcast(fmt.Sprintf("proposal for block #%d (round %d)", block.Index, round), EncodeProposal(round, block))
+}
+
+// BroadcastVote gossips a vote to all connected peers
+func (p2p *P2PNetwork) BroadcastVote(vote Vote) {
+	p2p.broadcast(fmt.Sprintf("%s of %s (round %d)", vote.Type, vote.ValidatorID, vote.Round), EncodeVote(vote))
+}
+
+// broadcast delivers framed messages to every peer over a fresh connection
+func (p2p *P2PNetwork) broadcast(description string, frames ...[]byte) {
 	for _, peer := range p2p.Peers {
 		conn, err := net.Dial("tcp", peer)
 		if err != nil {
 			fmt.Println("❌ Failed to connect to peer:", err)
 			continue
 		}
-		defer conn.Close()
 
-		conn.Write([]byte("BLOCKCHAIN_UPDATE " + string(blockchainData) + "\n"))
-		fmt.Println("🔄 Sent blockchain update to", peer)
+		err = p2p.handshake(conn)
+		for _, frame := range frames {
+			if err != nil {
+				break
+			}
+			err = writeFrame(conn, frame, p2p.maxFrameSize())
+		}
+		conn.Close()
+		if err != nil {
+			fmt.Println("❌ Failed to send to peer:", err)
+			continue
+		}
+		fmt.Printf("📣 Sent %s to %s\n", description, peer)
+	}
+}
+
+// handshakeTimeout bounds the exchange of hello messages
+const handshakeTimeout = 10 * time.Second
+
+// handshake opens every connection: both sides send a hello naming their chain ID and
+// genesis block hash, and the connection is dropped unless both match
+func (p2p *P2PNetwork) handshake(conn net.Conn) error {
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	defer conn.SetDeadline(time.Time{})
+
+	if err := writeFrame(conn, encodeHello(p2p.Blockchain.Config.ChainID, p2p.Blockchain.GenesisHash), p2p.maxFrameSize()); err != nil {
+		return err
+	}
+	payload, err := readFrame(conn, p2p.maxFrameSize())
+	if err != nil {
+		return err
+	}
+	chainID, genesisHash, err := decodeHello(payload)
+	if err != nil {
+		return err
+	}
+
+	if chainID != p2p.Blockchain.Config.ChainID || genesisHash != p2p.Blockchain.GenesisHash {
+		return fmt.Errorf("peer is on chain %s with genesis %s, we are on %s with genesis %s",
+			chainID, genesisHash, p2p.Blockchain.Config.ChainID, p2p.Blockchain.GenesisHash)
+	}
+	return nil
+}
+
+// ============================
+// Wire framing
+// ============================
+
+// frameOverhead bounds what a block message holds besides its transactions: the header, the
+// proposer's signature and the commit certificate
+const frameOverhead = 64 << 10
+
+// maxFrameSize bounds a single peer message: the largest is a block, whose transactions take
+// at most MaxBlockBytes
+func (p2p *P2PNetwork) maxFrameSize() int {
+	return p2p.Blockchain.Config.Params.MaxBlockBytes + frameOverhead
+}
+
+// writeFrame writes one message as [payload length uint32 big-endian][payload]
+func writeFrame(w io.Writer, payload []byte, maxSize int) error {
+	if len(payload) > maxSize {
+		return fmt.Errorf("message of %d bytes exceeds frame limit", len(payload))
+	}
+	frame := make([]byte, 4, 4+len(payload))
+	binary.BigEndian.PutUint32(frame, uint32(len(payload)))
+	_, err := w.Write(append(frame, payload...))
+	return err
+}
+
+// readFrame reads one message written by writeFrame
+func readFrame(r io.Reader, maxSize int) ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	if int64(size) > int64(maxSize) {
+		return nil, fmt.Errorf("frame of %d bytes exceeds limit", size)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
 	}
+	return payload, nil
 }
@@ -0,0 +1,120 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ============================
+// Proof-of-Authority
+// ============================
+
+// PoAConsensus is a round-robin Proof-of-Authority engine: the validators, ordered by
+// address, take turns proposing blocks, and a block is final once its proposer signs it
+type PoAConsensus struct {
+	validators *ValidatorSet
+	Params     ChainParams
+}
+
+// NewPoAConsensus initializes PoA with the genesis validator set as authorities
+func NewPoAConsensus(genesis *Genesis) *PoAConsensus {
+	return &PoAConsensus{
+		validators: NewValidatorSet(genesis.NewValidators()),
+		Params:     genesis.Params,
+	}
+}
+
+// Name returns the engine name
+func (poa *PoAConsensus) Name() string {
+	return EnginePoA
+}
+
+// Validators returns the authority set
+func (poa *PoAConsensus) Validators() *ValidatorSet {
+	return poa.validators
+}
+
+// SelectProposer returns the active authority whose turn it is in the block after state.
+// PoA blocks are sealed in round 0; a later round passes the turn on.
+func (poa *PoAConsensus) SelectProposer(state *State, prevHash string, round int) *Validator {
+	validators := poa.validators.Active(state)
+	if len(validators) == 0 {
+		return nil
+	}
+	return validators[(state.Height()+1+round)%len(validators)]
+}
+
+// Finalize accepts the block as is: the proposer's signature is the authority's seal
+func (poa *PoAConsensus) Finalize(block *Block) error {
+	fmt.Printf("🔏 Block #%d sealed by authority %s\n", block.Index, block.Proposer)
+	return nil
+}
+
+// ValidateProposal rejects commit certificates, which PoA blocks do not carry
+func (poa *PoAConsensus) ValidateProposal(state *State, block Block) error {
+	if block.Commit != nil || len(block.Approvals) > 0 {
+		return errors.New("proof-of-authority blocks carry no commit certificate or approvals")
+	}
+	return nil
+}
+
+// Rewards pays the block reward to the proposing authority
+func (poa *PoAConsensus) Rewards(parent Block, proposer string) []Payout {
+	if poa.validators.ByAddress(proposer) == nil || poa.Params.BlockReward <= 0 {
+		return nil
+	}
+	return []Payout{{Address: proposer, Amount: int64(poa.Params.BlockReward)}}
+}
+
+// ============================
+// Instant seal (development)
+// ============================
+
+// DevConsensus seals every block instantly: the first validator by address proposes
+// every block, nothing is voted on and no rewards are paid. Mining is fully
+// deterministic, which makes it suited to tests and local development.
+type DevConsensus struct {
+	validators *ValidatorSet
+}
+
+// NewDevConsensus initializes the instant-seal engine with the genesis validator set
+func NewDevConsensus(genesis *Genesis) *DevConsensus {
+	return &DevConsensus{validators: NewValidatorSet(genesis.NewValidators())}
+}
+
+// Name returns the engine name
+func (dev *DevConsensus) Name() string {
+	return EngineDev
+}
+
+// Validators returns the validator set
+func (dev *DevConsensus) Validators() *ValidatorSet {
+	return dev.validators
+}
+
+// SelectProposer returns the first active validator by address: instantly sealed blocks
+// only have round 0, later rounds move to the next validator
+func (dev *DevConsensus) SelectProposer(state *State, prevHash string, round int) *Validator {
+	validators := dev.validators.Active(state)
+	if len(validators) == 0 {
+		return nil
+	}
+	return validators[round%len(validators)]
+}
+
+// Finalize seals the block immediately
+func (dev *DevConsensus) Finalize(block *Block) error {
+	fmt.Printf("⚡ Block #%d sealed instantly\n", block.Index)
+	return nil
+}
+
+// ValidateProposal rejects commit certificates, which instantly sealed blocks do not carry
+func (dev *DevConsensus) ValidateProposal(state *State, block Block) error {
+	if block.Commit != nil || len(block.Approvals) > 0 {
+		return errors.New("instantly sealed blocks carry no commit certificate or approvals")
+	}
+	return nil
+}
+
+// Rewards pays nothing
+func (dev *DevConsensus) Rewards(parent Block, proposer string) []Payout { return nil }
@@ -0,0 +1,90 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrFileNotNotarized is returned when no block contains a transaction for the file hash
+var ErrFileNotNotarized = errors.New("file not notarized in any block")
+
+// InclusionProof proves that a file hash was sealed in a block. It carries everything
+// needed to recompute the block hash, so it can be checked offline against a block hash
+// obtained from any source (another node, a published checkpoint, ...).
+type InclusionProof struct {
+	BlockHash   string       `json:"block_hash"`
+	Header      BlockHeader  `json:"header"`
+	Transaction Transaction  `json:"transaction"`
+	TxIndex     int          `json:"tx_index"`
+	TxCount     int          `json:"tx_count"`
+	MerklePath  []MerkleStep `json:"merkle_path"`
+}
+
+// FindFile returns the block and transaction that notarized the given file hash
+func (bc *Blockchain) FindFile(fileHash string) (Block, Transaction, error) {
+	// ✅ The state records where each file was notarized
+	record, err := bc.FileRecord(fileHash)
+	if err != nil {
+		return Block{}, Transaction{}, err
+	}
+	chain := bc.ChainSnapshot()
+
+	if record.Height < len(chain) {
+		block := chain[record.Height]
+		for _, tx := range block.Transactions {
+			if tx.TxID == record.TxID {
+				return block, tx, nil
+			}
+		}
+	}
+	return Block{}, Transaction{}, fmt.Errorf("%w: %s", ErrFileNotNotarized, fileHash)
+}
+
+// ProveFile builds an inclusion proof for the block that notarized the given file hash
+func (bc *Blockchain) ProveFile(fileHash string) (*InclusionProof, error) {
+	block, tx, err := bc.FindFile(fileHash)
+	if err != nil {
+		return nil, err
+	}
+
+	merkleProof, err := block.ProveTransaction(tx.TxID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &InclusionProof{
+		BlockHash:   block.Hash,
+		Header:      block.BlockHeader,
+		Transaction: tx,
+		TxIndex:     merkleProof.Index,
+		TxCount:     merkleProof.Leaves,
+		MerklePath:  merkleProof.Path,
+	}, nil
+}
+
+// VerifyInclusionProof checks, without contacting any node, that the proof binds fileHash
+// to proof.BlockHash: the transaction names the file, its TxID is recomputed from its
+// contents, the Merkle path leads to the Merkle root, and the block header hashes to BlockHash.
+func VerifyInclusionProof(proof InclusionProof, fileHash string) error {
+	tx := proof.Transaction
+	if !tx.isFile() || tx.File.FileHash != fileHash {
+		return fmt.Errorf("proof is not for file %s", fileHash)
+	}
+	if tx.calculateTxID() != tx.TxID {
+		return errors.New("transaction ID does not match transaction contents")
+	}
+
+	merkleProof := MerkleProof{TxID: tx.TxID, Index: proof.TxIndex, Leaves: proof.TxCount, Path: proof.MerklePath}
+	if !VerifyMerkleProof(proof.Header.MerkleRoot, merkleProof) {
+		return errors.New("merkle path does not lead to the block's merkle root")
+	}
+
+	hash, err := proof.Header.ComputeHash()
+	if err != nil {
+		return err
+	}
+	if hash != proof.BlockHash {
+		return errors.New("block header does not hash to the block hash")
+	}
+	return nil
+}
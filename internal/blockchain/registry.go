@@ -0,0 +1,164 @@
+package blockchain
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Limits of the metadata a file may carry on chain
+const (
+	MaxMetadataFields = 16  // Fields per file
+	MaxMetadataKey    = 64  // Bytes per field name
+	MaxMetadataValue  = 256 // Bytes per field value and per revocation reason
+)
+
+// FileRecord is what the chain says about a notarized file: who notarized it where, its
+// metadata and whether its uploader revoked it
+type FileRecord struct {
+	FileHash         string            `json:"file_hash"`
+	Uploader         string            `json:"uploader"`
+	Height           int               `json:"height"` // Block notarizing the file
+	TxID             string            `json:"tx_id"`  // Transaction notarizing the file
+	Metadata         map[string]string `json:"metadata,omitempty"`
+	Revoked          bool              `json:"revoked"`
+	RevokedAt        int               `json:"revoked_at,omitempty"` // Block holding the revocation
+	RevocationReason string            `json:"revocation_reason,omitempty"`
+}
+
+// MetadataUpdate sets metadata fields of a file notarized by the sender. A field with an
+// empty value is removed.
+type MetadataUpdate struct {
+	Sender
+	FileHash string
+	Fields   map[string]string
+}
+
+// Revocation withdraws a file notarized by the sender, e.g. a document that was superseded.
+// The notarization stays on chain, marked revoked; the file cannot be notarized again.
+type Revocation struct {
+	Sender
+	FileHash string
+	Reason   string
+}
+
+// NewMetadataTransaction returns an unsigned metadata update of a file (see SignTransaction)
+func NewMetadataTransaction(fileHash string, fields map[string]string) Transaction {
+	return Transaction{Type: TxMetadata, Metadata: &MetadataUpdate{FileHash: fileHash, Fields: fields}}
+}
+
+// NewRevocationTransaction returns an unsigned revocation of a file (see SignTransaction)
+func NewRevocationTransaction(fileHash string, reason string) Transaction {
+	return Transaction{Type: TxRevocation, Revocation: &Revocation{FileHash: fileHash, Reason: reason}}
+}
+
+// sortedKeys returns the field names of metadata in the order they are encoded
+func sortedKeys(fields map[string]string) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// checkMetadataUpdate checks the file hash and the number and size of the fields
+func checkMetadataUpdate(tx *Transaction) error {
+	update := tx.Metadata
+	if err := checkFileHash(update.FileHash); err != nil {
+		return err
+	}
+	if len(update.Fields) == 0 || len(update.Fields) > MaxMetadataFields {
+		return fmt.Errorf("%d metadata fields, between 1 and %d are allowed", len(update.Fields), MaxMetadataFields)
+	}
+	for key, value := range update.Fields {
+		if key == "" || len(key) > MaxMetadataKey {
+			return fmt.Errorf("metadata field name %q is empty or longer than %d bytes", key, MaxMetadataKey)
+		}
+		if len(value) > MaxMetadataValue {
+			return fmt.Errorf("metadata field %q is longer than %d bytes", key, MaxMetadataValue)
+		}
+	}
+	return nil
+}
+
+// checkRevocation checks the file hash and the length of the reason
+func checkRevocation(tx *Transaction) error {
+	if err := checkFileHash(tx.Revocation.FileHash); err != nil {
+		return err
+	}
+	if len(tx.Revocation.Reason) > MaxMetadataValue {
+		return fmt.Errorf("revocation reason is longer than %d bytes", MaxMetadataValue)
+	}
+	return nil
+}
+
+// ownedFile returns the record of a file the sender notarized and has not revoked
+func ownedFile(state *State, fileHash string, sender string) (*FileRecord, error) {
+	record, ok := state.files[fileHash]
+	if !ok {
+		return nil, fmt.Errorf("file %s is not notarized", fileHash)
+	}
+	if record.Uploader != sender {
+		return nil, fmt.Errorf("file %s was notarized by %s, not %s", fileHash, record.Uploader, sender)
+	}
+	if record.Revoked {
+		return nil, fmt.Errorf("file %s was revoked in block #%d", fileHash, record.RevokedAt)
+	}
+	return record, nil
+}
+
+// applyMetadataUpdate sets the fields of a file; the file must be the sender's and not revoked
+func applyMetadataUpdate(state *State, tx Transaction) error {
+	update := tx.Metadata
+	if err := state.ledger.checkNonce(update.Sender); err != nil {
+		return err
+	}
+	record, err := ownedFile(state, update.FileHash, update.From)
+	if err != nil {
+		return err
+	}
+
+	metadata := make(map[string]string, len(record.Metadata)+len(update.Fields))
+	for key, value := range record.Metadata {
+		metadata[key] = value
+	}
+	for key, value := range update.Fields {
+		if value == "" {
+			delete(metadata, key)
+		} else {
+			metadata[key] = value
+		}
+	}
+	if len(metadata) > MaxMetadataFields {
+		return fmt.Errorf("file %s would carry %d metadata fields, %d are allowed", update.FileHash, len(metadata), MaxMetadataFields)
+	}
+
+	state.ledger.useNonce(update.From)
+	record.Metadata = metadata
+	return nil
+}
+
+// applyRevocation marks a file revoked; the file must be the sender's and not revoked yet
+func applyRevocation(state *State, tx Transaction) error {
+	revocation := tx.Revocation
+	if err := state.ledger.checkNonce(revocation.Sender); err != nil {
+		return err
+	}
+	record, err := ownedFile(state, revocation.FileHash, revocation.From)
+	if err != nil {
+		return err
+	}
+
+	state.ledger.useNonce(revocation.From)
+	record.Revoked, record.RevokedAt, record.RevocationReason = true, state.height, revocation.Reason
+	return nil
+}
+
+// FileRecord returns the registry entry of a file on the canonical chain
+func (bc *Blockchain) FileRecord(fileHash string) (FileRecord, error) {
+	record, ok := bc.State().File(fileHash)
+	if !ok {
+		return FileRecord{}, fmt.Errorf("%w: %s", ErrFileNotNotarized, fileHash)
+	}
+	return record, nil
+}
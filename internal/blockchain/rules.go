@@ -0,0 +1,174 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Block header versions
+const (
+	BlockVersion1 uint32 = 1 // Header: index, timestamp, previous hash, Merkle root
+	BlockVersion2 uint32 = 2 // Adds chain ID and proposer address to the hashed header
+	BlockVersion3 uint32 = 3 // Same header as version 2; the proposer must be the scheduled validator
+	BlockVersion4 uint32 = 4 // Adds the state root after the block to the hashed header
+	BlockVersion5 uint32 = 5 // Same header as version 4; transactions may pay fees to the proposer
+	BlockVersion6 uint32 = 6 // Same header as version 4; an uploader can use each upload nonce once
+	BlockVersion7 uint32 = 7 // Same header as version 4; slashing takes bonded QRY first and jailed validators cannot unstake
+)
+
+// NetworkUpgrade activates a block version (and its validation rules) from a block height on
+type NetworkUpgrade struct {
+	Name         string `json:"name"`
+	Height       int    `json:"height"`        // First block height the upgrade applies to
+	BlockVersion uint32 `json:"block_version"` // Header version required from Height on
+}
+
+// ChainConfig identifies a network, its consensus parameters and its rule upgrades.
+// It is derived from the genesis file (see Genesis.Config).
+type ChainConfig struct {
+	ChainID  string
+	Params   ChainParams
+	Upgrades []NetworkUpgrade // Sorted by height; the first must start at 0
+}
+
+// DefaultUpgrades is the schedule of new networks: the latest rules from genesis on
+func DefaultUpgrades() []NetworkUpgrade {
+	return []NetworkUpgrade{
+		{Name: "bond-slashing", Height: 0, BlockVersion: BlockVersion7},
+	}
+}
+
+// BlockRules are the validation rules of one block header version
+type BlockRules struct {
+	Version           uint32
+	Description       string
+	ScheduledProposer bool                                                         // Proposer must be ConsensusEngine.SelectProposer
+	StateRoot         bool                                                         // Header commits to the state root after the block
+	Fees              bool                                                         // Transactions may pay a fee to the proposer
+	CommitCertificate bool                                                         // PoD blocks are final by a commit certificate, not approvals
+	UploadNonces      bool                                                         // An uploader's signed upload cannot be replayed with its nonce
+	BondSlashing      bool                                                         // Slashing takes bonded QRY before the balance; jailed validators cannot unstake
+	check             func(block Block, proposer string, config ChainConfig) error // Extra header checks
+}
+
+// blockRules registers every block version ever activated; old versions stay here so
+// blocks produced under them remain valid after an upgrade
+var blockRules = map[uint32]BlockRules{
+	BlockVersion1: {
+		Version:     BlockVersion1,
+		Description: "unversioned header without chain ID or proposer",
+		check: func(block Block, proposer string, config ChainConfig) error {
+			// ✅ Fields outside the hashed header must be empty, or they could be altered freely
+			if block.ChainID != "" || block.Proposer != "" {
+				return errors.New("version 1 blocks carry no chain ID or proposer")
+			}
+			return nil
+		},
+	},
+	BlockVersion2: {
+		Version:     BlockVersion2,
+		Description: "header commits to the chain ID and proposer address",
+		check:       checkProposerHeader,
+	},
+	BlockVersion3: {
+		Version:           BlockVersion3,
+		Description:       "block is proposed by the stake-weighted scheduled validator",
+		ScheduledProposer: true,
+		check:             checkProposerHeader,
+	},
+	BlockVersion4: {
+		Version:           BlockVersion4,
+		Description:       "header commits to the state root after the block",
+		ScheduledProposer: true,
+		StateRoot:         true,
+		CommitCertificate: true,
+		check:             checkProposerHeader,
+	},
+	BlockVersion5: {
+		Version:           BlockVersion5,
+		Description:       "transactions may pay a fee to the block proposer",
+		ScheduledProposer: true,
+		StateRoot:         true,
+		Fees:              true,
+		CommitCertificate: true,
+		check:             checkProposerHeader,
+	},
+	BlockVersion6: {
+		Version:           BlockVersion6,
+		Description:       "an uploader can use each upload nonce once",
+		ScheduledProposer: true,
+		StateRoot:         true,
+		Fees:              true,
+		CommitCertificate: true,
+		UploadNonces:      true,
+		check:             checkProposerHeader,
+	},
+	BlockVersion7: {
+		Version:           BlockVersion7,
+		Description:       "slashing takes bonded QRY first and jailed validators cannot unstake",
+		ScheduledProposer: true,
+		StateRoot:         true,
+		Fees:              true,
+		CommitCertificate: true,
+		UploadNonces:      true,
+		BondSlashing:      true,
+		check:             checkProposerHeader,
+	},
+}
+
+// checkProposerHeader checks the chain ID and proposer committed to by version 2+ headers
+func checkProposerHeader(block Block, proposer string, config ChainConfig) error {
+	if block.ChainID != config.ChainID {
+		return fmt.Errorf("chain ID %q, expected %q", block.ChainID, config.ChainID)
+	}
+	if block.Proposer != proposer {
+		return fmt.Errorf("proposer %s does not match block signer %s", block.Proposer, proposer)
+	}
+	return nil
+}
+
+// Validate checks the parameters and that the upgrade schedule starts at genesis, is ordered
+// and only names known versions
+func (c ChainConfig) Validate() error {
+	if c.ChainID == "" {
+		return errors.New("chain ID is empty")
+	}
+	if err := c.Params.Validate(); err != nil {
+		return err
+	}
+	if len(c.Upgrades) == 0 || c.Upgrades[0].Height != 0 {
+		return errors.New("upgrade schedule must start at height 0")
+	}
+	for i, upgrade := range c.Upgrades {
+		if _, ok := blockRules[upgrade.BlockVersion]; !ok {
+			return fmt.Errorf("upgrade %q: unknown block version %d", upgrade.Name, upgrade.BlockVersion)
+		}
+		if i > 0 && upgrade.Height <= c.Upgrades[i-1].Height {
+			return fmt.Errorf("upgrade %q: heights must be strictly increasing", upgrade.Name)
+		}
+	}
+	return nil
+}
+
+// RulesAt returns the rules in force at a block height: those of the last upgrade activated at or below it
+func (c ChainConfig) RulesAt(height int) BlockRules {
+	i := sort.Search(len(c.Upgrades), func(i int) bool { return c.Upgrades[i].Height > height })
+	if i == 0 {
+		return blockRules[BlockVersion1]
+	}
+	return blockRules[c.Upgrades[i-1].BlockVersion]
+}
+
+// NewHeader returns the header of a block at the given height under the rules active there
+func (c ChainConfig) NewHeader(index int, previousHash string) BlockHeader {
+	header := BlockHeader{
+		Version:      c.RulesAt(index).Version,
+		Index:        index,
+		PreviousHash: previousHash,
+	}
+	if header.Version >= BlockVersion2 {
+		header.ChainID = c.ChainID
+	}
+	return header
+}
@@ -0,0 +1,225 @@
+package blockchain
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// KeyType identifies a signature scheme; it tags addresses, public keys and signatures
+type KeyType string
+
+// Supported signature schemes
+const (
+	KeyTypeP256    KeyType = "p256"    // ECDSA on P-256 over SHA-256, low-S, 64-byte r||s
+	KeyTypeEd25519 KeyType = "ed25519" // Ed25519 (RFC 8032) over the raw message
+)
+
+// ErrUnsupportedKeyType is returned for key types without a registered scheme
+var ErrUnsupportedKeyType = errors.New("unsupported key type")
+
+// Verifier checks signatures made with one public key
+type Verifier interface {
+	KeyType() KeyType
+	Bytes() []byte // Canonical public key encoding (compressed point for P-256)
+	Verify(message, signature []byte) bool
+}
+
+// Signer signs messages with one private key
+type Signer interface {
+	Public() Verifier
+	PrivateKey() crypto.PrivateKey
+	Sign(message []byte) ([]byte, error)
+}
+
+// GenerateSigner creates a new random key of the given type
+func GenerateSigner(keyType KeyType) (Signer, error) {
+	switch keyType {
+	case KeyTypeP256:
+		privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return &p256Signer{privateKey: privateKey}, nil
+	case KeyTypeEd25519:
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return ed25519Signer(privateKey), nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedKeyType, keyType)
+}
+
+// NewSigner wraps a parsed private key (*ecdsa.PrivateKey on P-256 or ed25519.PrivateKey)
+func NewSigner(privateKey crypto.PrivateKey) (Signer, error) {
+	switch key := privateKey.(type) {
+	case *ecdsa.PrivateKey:
+		if key.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("%w: ECDSA curve %s", ErrUnsupportedKeyType, key.Curve.Params().Name)
+		}
+		return &p256Signer{privateKey: key}, nil
+	case ed25519.PrivateKey:
+		return ed25519Signer(key), nil
+	}
+	return nil, fmt.Errorf("%w: %T", ErrUnsupportedKeyType, privateKey)
+}
+
+// ParsePublicKey decodes a hex public key of the given type
+func ParsePublicKey(keyType KeyType, publicKeyHex string) (Verifier, error) {
+	keyBytes, err := hex.DecodeString(publicKeyHex)
+	if err != nil {
+		return nil, fmt.Errorf("public key is not hex: %w", err)
+	}
+
+	switch keyType {
+	case KeyTypeP256:
+		curve := elliptic.P256()
+		x, y := elliptic.UnmarshalCompressed(curve, keyBytes)
+		if x == nil {
+			return nil, errors.New("not a compressed P-256 public key")
+		}
+		return &p256Verifier{publicKey: &ecdsa.PublicKey{Curve: curve, X: x, Y: y}}, nil
+	case KeyTypeEd25519:
+		if len(keyBytes) != ed25519.PublicKeySize {
+			return nil, errors.New("not an Ed25519 public key")
+		}
+		return ed25519Verifier(keyBytes), nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedKeyType, keyType)
+}
+
+// EncodePublicKey encodes a public key as hex of its canonical bytes
+func EncodePublicKey(publicKey Verifier) string {
+	return hex.EncodeToString(publicKey.Bytes())
+}
+
+// EncodeSignature tags signature bytes with their key type: "<key type>:<base64>"
+func EncodeSignature(keyType KeyType, signature []byte) string {
+	return string(keyType) + ":" + base64.StdEncoding.EncodeToString(signature)
+}
+
+// DecodeSignature splits a tagged signature into its key type and bytes. Only the encoding
+// produced by EncodeSignature is accepted, so the same signature cannot be re-encoded into
+// another string (and another TxID).
+func DecodeSignature(signature string) (KeyType, []byte, error) {
+	keyType, encoded, ok := strings.Cut(signature, ":")
+	if !ok {
+		return "", nil, errors.New("signature has no key type tag")
+	}
+	signatureBytes, err := base64.StdEncoding.Strict().DecodeString(encoded)
+	if err != nil {
+		return "", nil, fmt.Errorf("signature is not base64: %w", err)
+	}
+	// ✅ Strict decoding still skips line breaks
+	if base64.StdEncoding.EncodeToString(signatureBytes) != encoded {
+		return "", nil, errors.New("signature is not canonical base64")
+	}
+	return KeyType(keyType), signatureBytes, nil
+}
+
+// ============================
+// P-256 ECDSA
+// ============================
+
+// p256HalfOrder is n/2; signatures with s above it are rejected as non-canonical
+var p256HalfOrder = new(big.Int).Rsh(elliptic.P256().Params().N, 1)
+
+type p256Signer struct {
+	privateKey *ecdsa.PrivateKey
+}
+
+func (s *p256Signer) Public() Verifier {
+	return &p256Verifier{publicKey: &s.privateKey.PublicKey}
+}
+
+func (s *p256Signer) PrivateKey() crypto.PrivateKey {
+	return s.privateKey
+}
+
+// Sign hashes the message with SHA-256 and returns the low-S signature as 32-byte r || 32-byte s
+func (s *p256Signer) Sign(message []byte) ([]byte, error) {
+	hash := sha256.Sum256(message)
+	r, sig, err := ecdsa.Sign(rand.Reader, s.privateKey, hash[:])
+	if err != nil {
+		return nil, err
+	}
+
+	// ✅ Normalize to low-S so every signature has exactly one valid encoding
+	if sig.Cmp(p256HalfOrder) > 0 {
+		sig.Sub(elliptic.P256().Params().N, sig)
+	}
+
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	sig.FillBytes(signature[32:])
+	return signature, nil
+}
+
+type p256Verifier struct {
+	publicKey *ecdsa.PublicKey
+}
+
+func (v *p256Verifier) KeyType() KeyType {
+	return KeyTypeP256
+}
+
+func (v *p256Verifier) Bytes() []byte {
+	return elliptic.MarshalCompressed(v.publicKey.Curve, v.publicKey.X, v.publicKey.Y)
+}
+
+// Verify accepts only canonical 64-byte low-S signatures
+func (v *p256Verifier) Verify(message, signature []byte) bool {
+	if len(signature) != 64 {
+		return false
+	}
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	if s.Cmp(p256HalfOrder) > 0 {
+		return false
+	}
+
+	hash := sha256.Sum256(message)
+	return ecdsa.Verify(v.publicKey, hash[:], r, s)
+}
+
+// ============================
+// Ed25519
+// ============================
+
+type ed25519Signer ed25519.PrivateKey
+
+func (s ed25519Signer) Public() Verifier {
+	return ed25519Verifier(ed25519.PrivateKey(s).Public().(ed25519.PublicKey))
+}
+
+func (s ed25519Signer) PrivateKey() crypto.PrivateKey {
+	return ed25519.PrivateKey(s)
+}
+
+func (s ed25519Signer) Sign(message []byte) ([]byte, error) {
+	return ed25519.Sign(ed25519.PrivateKey(s), message), nil
+}
+
+type ed25519Verifier ed25519.PublicKey
+
+func (v ed25519Verifier) KeyType() KeyType {
+	return KeyTypeEd25519
+}
+
+func (v ed25519Verifier) Bytes() []byte {
+	return []byte(v)
+}
+
+func (v ed25519Verifier) Verify(message, signature []byte) bool {
+	return len(signature) == ed25519.SignatureSize && ed25519.Verify(ed25519.PublicKey(v), message, signature)
+}
@@ -0,0 +1,73 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInsufficientBond is returned for an unstake of more QRY than the validator has bonded
+var ErrInsufficientBond = errors.New("insufficient bonded stake")
+
+// StakeChange bonds QRY from a validator's balance to its voting power (TxStake), or releases
+// bonded QRY back to its balance (TxUnstake). The sender must be a validator.
+type StakeChange struct {
+	Sender
+	Amount int64 // QRY bonded or released, positive
+}
+
+// NewStakeTransaction returns an unsigned stake (or, if unstake is set, unstake) transaction
+// of amount QRY (see SignTransaction)
+func NewStakeTransaction(amount int64, unstake bool) Transaction {
+	tx := Transaction{Type: TxStake, Stake: &StakeChange{Amount: amount}}
+	if unstake {
+		tx.Type = TxUnstake
+	}
+	return tx
+}
+
+// checkStakeChange checks that the amount is positive
+func checkStakeChange(tx *Transaction) error {
+	if tx.Stake.Amount <= 0 {
+		return fmt.Errorf("amount %d is not positive", tx.Stake.Amount)
+	}
+	return nil
+}
+
+// stakingValidator checks that the sender of a stake change is a validator
+func stakingValidator(state *State, sender string) error {
+	if state.validatorByAddress(sender) == nil {
+		return fmt.Errorf("%s is not a validator", sender)
+	}
+	return nil
+}
+
+// unstakingValidator checks that the sender of an unstake is a validator that is not jailed
+// (from block version 7 on): a convicted validator's bond stays to be slashed
+func unstakingValidator(state *State, sender string) error {
+	v := state.validatorByAddress(sender)
+	if v == nil {
+		return fmt.Errorf("%s is not a validator", sender)
+	}
+	if v.Jailed && state.rules().BondSlashing {
+		return fmt.Errorf("validator %s is jailed and cannot unstake", v.ID)
+	}
+	return nil
+}
+
+// applyStake moves QRY from the validator's balance to its bonded stake
+func applyStake(state *State, tx Transaction) error {
+	change := tx.Stake
+	if err := stakingValidator(state, change.From); err != nil {
+		return err
+	}
+	return state.ledger.bond(change.Sender, change.Amount)
+}
+
+// applyUnstake moves bonded QRY back to the validator's balance
+func applyUnstake(state *State, tx Transaction) error {
+	change := tx.Stake
+	if err := unstakingValidator(state, change.From); err != nil {
+		return err
+	}
+	return state.ledger.unbond(change.Sender, change.Amount)
+}
@@ -0,0 +1,254 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// State is everything the chain decides: the QRY accounts, what it says about each validator
+// and the file registry. It is a pure function of the genesis file and the blocks: ApplyBlock
+// is the only way it changes, so nodes holding the same blocks hold the same state, and
+// block headers commit to its root (see Root).
+type State struct {
+	height     int                        // Height of the last block applied
+	proposer   string                     // Proposer of the last block applied, paid the fees
+	ledger     *Ledger                    // QRY accounts
+	validators map[string]*ValidatorState // Genesis validators by ID
+	files      map[string]*FileRecord     // Notarized files by hash
+	uploads    map[string]map[uint64]bool // Upload nonces used by each uploader (block version 6+)
+	params     ChainParams                // Parameters of the network; not part of the root
+	upgrades   []NetworkUpgrade           // Rule upgrade schedule of the network; not part of the root
+}
+
+// ValidatorState is what the chain says about a validator. Its bonded stake is in its
+// account (see Account.Bonded).
+type ValidatorState struct {
+	ID       string `json:"id"`
+	Address  string `json:"address"`
+	Stake    int64  `json:"stake"`               // Genesis stake
+	Jailed   bool   `json:"jailed"`              // Convicted of double signing
+	JailedAt int    `json:"jailed_at,omitempty"` // Block holding the evidence
+}
+
+// NewState returns the state before the first block: the genesis allocations and validators
+func NewState(genesis *Genesis) *State {
+	state := &State{
+		ledger:     NewLedger(genesis),
+		validators: make(map[string]*ValidatorState),
+		files:      make(map[string]*FileRecord),
+		uploads:    make(map[string]map[uint64]bool),
+	}
+	if genesis != nil {
+		state.params = genesis.Params
+		state.upgrades = genesis.Upgrades
+		for _, v := range genesis.NewValidators() {
+			state.validators[v.ID] = &ValidatorState{ID: v.ID, Address: v.Address, Stake: v.Stake}
+		}
+	}
+	return state
+}
+
+// ApplyBlock is the state transition function: it returns the state after block, leaving
+// state untouched. It only applies the transactions; their signatures, the header and the
+// consensus data are checked by ValidateBlock.
+func ApplyBlock(state *State, block Block) (*State, error) {
+	next := state.Clone()
+	if err := next.applyBlock(block); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// applyBlock applies a block in place; the state is left half-applied if it fails
+func (s *State) applyBlock(block Block) error {
+	s.height, s.proposer = block.Index, block.Proposer
+	for _, tx := range block.Transactions {
+		if err := s.applyTransaction(tx); err != nil {
+			return fmt.Errorf("transaction %s: %w", tx.TxID, err)
+		}
+	}
+	return nil
+}
+
+// applyTransaction applies a transaction with the rules of its type, after its payer paid the
+// fee; the state is unchanged if they do not allow it
+func (s *State) applyTransaction(tx Transaction) error {
+	t, ok := txTypes[tx.Type]
+	if !ok || !t.payload(&tx) {
+		return fmt.Errorf("unknown transaction type %q", tx.Type)
+	}
+	if tx.Fee == 0 {
+		return t.apply(s, tx)
+	}
+
+	if err := s.payFee(tx); err != nil {
+		return err
+	}
+	if err := t.apply(s, tx); err != nil {
+		s.refundFee(tx)
+		return err
+	}
+	return nil
+}
+
+// Clone returns a deep copy of the state
+func (s *State) Clone() *State {
+	clone := &State{
+		height:     s.height,
+		proposer:   s.proposer,
+		ledger:     &Ledger{accounts: make(map[string]*Account, len(s.ledger.accounts))},
+		validators: make(map[string]*ValidatorState, len(s.validators)),
+		files:      make(map[string]*FileRecord, len(s.files)),
+		uploads:    make(map[string]map[uint64]bool, len(s.uploads)),
+		params:     s.params,
+		upgrades:   s.upgrades,
+	}
+	for address, account := range s.ledger.accounts {
+		copied := *account
+		clone.ledger.accounts[address] = &copied
+	}
+	for id, v := range s.validators {
+		copied := *v
+		clone.validators[id] = &copied
+	}
+	for hash, record := range s.files {
+		copied := *record
+		if record.Metadata != nil {
+			copied.Metadata = make(map[string]string, len(record.Metadata))
+			for key, value := range record.Metadata {
+				copied.Metadata[key] = value
+			}
+		}
+		clone.files[hash] = &copied
+	}
+	for uploader, nonces := range s.uploads {
+		copied := make(map[uint64]bool, len(nonces))
+		for nonce := range nonces {
+			copied[nonce] = true
+		}
+		clone.uploads[uploader] = copied
+	}
+	return clone
+}
+
+// rules returns the rules of the block being applied (see ChainConfig.RulesAt)
+func (s *State) rules() BlockRules {
+	return ChainConfig{Upgrades: s.upgrades}.RulesAt(s.height)
+}
+
+// Root returns the state root: the SHA-256 of the canonical state encoding (hex). Version 4+
+// block headers commit to the root of the state after the block (see docs/state.md).
+func (s *State) Root() string {
+	hash := sha256.Sum256(s.Bytes())
+	return hex.EncodeToString(hash[:])
+}
+
+// Height returns the height of the last block applied
+func (s *State) Height() int {
+	return s.height
+}
+
+// Account returns the account of an address (empty if it never received QRY)
+func (s *State) Account(address string) Account {
+	return s.ledger.Account(address)
+}
+
+// Validator returns what the chain says about a genesis validator
+func (s *State) Validator(id string) (ValidatorState, bool) {
+	v, ok := s.validators[id]
+	if !ok {
+		return ValidatorState{}, false
+	}
+	return *v, true
+}
+
+// VotingPower returns a validator's voting power in the block after the state: its genesis
+// stake plus the QRY it bonded with stake transactions (0 for an unknown validator)
+func (s *State) VotingPower(id string) int64 {
+	v, ok := s.validators[id]
+	if !ok {
+		return 0
+	}
+	return v.Stake + s.ledger.Account(v.Address).Bonded
+}
+
+// validatorByAddress returns the validator with the given address, or nil
+func (s *State) validatorByAddress(address string) *ValidatorState {
+	for _, v := range s.validators {
+		if v.Address == address {
+			return v
+		}
+	}
+	return nil
+}
+
+// File returns the registry entry of a notarized file
+func (s *State) File(fileHash string) (FileRecord, bool) {
+	record, ok := s.files[fileHash]
+	if !ok {
+		return FileRecord{}, false
+	}
+	return *record, true
+}
+
+// UploadNonceUsed reports whether an uploader already used a nonce in an upload recorded from
+// block version 6 on
+func (s *State) UploadNonceUsed(uploader string, nonce uint64) bool {
+	return s.uploads[uploader][nonce]
+}
+
+// Files returns the number of notarized files
+func (s *State) Files() int {
+	return len(s.files)
+}
+
+// ============================
+// State of the canonical chain
+// ============================
+
+// replayState returns the state after a chain whose blocks were validated; an error means
+// the chain does not apply, so the state would diverge from the one its headers commit to
+func (bc *Blockchain) replayState(chain []Block) (*State, error) {
+	state := NewState(bc.Genesis)
+	for _, block := range chain {
+		if err := state.applyBlock(block); err != nil {
+			return nil, fmt.Errorf("replay block #%d: %w", block.Index, err)
+		}
+	}
+	return state, nil
+}
+
+// State returns the state of the canonical chain; it is never modified, a new chain head
+// replaces it
+func (bc *Blockchain) State() *State {
+	bc.stateMu.RLock()
+	defer bc.stateMu.RUnlock()
+
+	return bc.state
+}
+
+// ChainSnapshot returns the canonical chain. A new chain head replaces the slice and never
+// modifies it, so the snapshot can be read without holding any lock
+func (bc *Blockchain) ChainSnapshot() []Block {
+	bc.stateMu.RLock()
+	defer bc.stateMu.RUnlock()
+
+	return bc.Chain
+}
+
+// Head returns the head of the canonical chain and the state after it, read together
+func (bc *Blockchain) Head() (Block, *State) {
+	bc.stateMu.RLock()
+	defer bc.stateMu.RUnlock()
+
+	return bc.Chain[len(bc.Chain)-1], bc.state
+}
+
+// setHead makes chain the canonical chain and state the state after it. Caller must hold bc.mu.
+func (bc *Blockchain) setHead(chain []Block, state *State) {
+	bc.stateMu.Lock()
+	bc.Chain = chain
+	bc.state = state
+	bc.stateMu.Unlock()
+}
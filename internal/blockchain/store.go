@@ -0,0 +1,292 @@
+package blockchain
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// blockLogName is the append-only block log inside the data directory
+const blockLogName = "blocks.log"
+
+// recordHeaderSize is the size of a record frame: [length uint32][crc32 uint32]
+const recordHeaderSize = 8
+
+// ErrBlockNotFound is returned when a block is not present in the store
+var ErrBlockNotFound = errors.New("block not found")
+
+// BlockStore is a durable, append-only block log with an in-memory index by height and hash.
+// Every record is framed as [payload length][CRC-32 of payload][JSON block] and fsynced on write,
+// so a crash can at worst leave a torn final record or a zero-filled tail, which are truncated
+// on the next open.
+type BlockStore struct {
+	dir     string
+	file    *os.File
+	size    int64          // Size of the valid part of the log
+	offsets []int64        // Record offset by block height
+	byHash  map[string]int // Block height by block hash
+	mu      sync.Mutex
+}
+
+// OpenBlockStore opens (or creates) the block log in dataDir and rebuilds its index
+func OpenBlockStore(dataDir string) (*BlockStore, error) {
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data directory: %w", err)
+	}
+
+	file, err := os.OpenFile(filepath.Join(dataDir, blockLogName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open block log: %w", err)
+	}
+
+	s := &BlockStore{
+		dir:    dataDir,
+		file:   file,
+		byHash: make(map[string]int),
+	}
+	if err := s.recover(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// recover scans the log, rebuilds the index and truncates a torn final record
+func (s *BlockStore) recover() error {
+	info, err := s.file.Stat()
+	if err != nil {
+		return fmt.Errorf("stat block log: %w", err)
+	}
+	fileSize := info.Size()
+
+	var offset int64
+	for offset < fileSize {
+		block, next, err := s.readRecord(offset, fileSize)
+		if err == nil && block.Index != len(s.offsets) {
+			err = fmt.Errorf("block log out of order: expected height %d, found %d", len(s.offsets), block.Index)
+		}
+		if err != nil {
+			if !errors.Is(err, errTornRecord) {
+				return fmt.Errorf("block log corrupted at offset %d: %w", offset, err)
+			}
+
+			// ✅ Only the final record can be torn by a crash: drop it and keep the rest
+			fmt.Printf("⚠ Truncating torn block record at offset %d (%d bytes)\n", offset, fileSize-offset)
+			if err := s.file.Truncate(offset); err != nil {
+				return fmt.Errorf("truncate torn record: %w", err)
+			}
+			if err := s.file.Sync(); err != nil {
+				return fmt.Errorf("sync block log: %w", err)
+			}
+			break
+		}
+
+		s.offsets = append(s.offsets, offset)
+		s.byHash[block.Hash] = block.Index
+		offset = next
+	}
+
+	s.size = offset
+	return nil
+}
+
+// errTornRecord marks a final record that was only partially written, or a zero-filled tail
+// left by a crash after the file grew but before its data reached the disk
+var errTornRecord = errors.New("torn record")
+
+// readRecord decodes the record at offset and returns the offset of the next one
+func (s *BlockStore) readRecord(offset, fileSize int64) (Block, int64, error) {
+	var block Block
+
+	if fileSize-offset < recordHeaderSize {
+		return block, 0, errTornRecord
+	}
+	header := make([]byte, recordHeaderSize)
+	if _, err := s.file.ReadAt(header, offset); err != nil {
+		return block, 0, err
+	}
+	length := int64(binary.BigEndian.Uint32(header[0:4]))
+	checksum := binary.BigEndian.Uint32(header[4:8])
+
+	// ✅ No block encodes to an empty payload: an empty record is the start of a zero-filled tail
+	if length == 0 {
+		if s.zeroFrom(offset, fileSize) {
+			return block, 0, errTornRecord
+		}
+		return block, 0, errors.New("empty record")
+	}
+
+	end := offset + recordHeaderSize + length
+	if end > fileSize {
+		return block, 0, errTornRecord
+	}
+	payload := make([]byte, length)
+	if _, err := s.file.ReadAt(payload, offset+recordHeaderSize); err != nil && err != io.EOF {
+		return block, 0, err
+	}
+	if crc32.ChecksumIEEE(payload) != checksum {
+		if end == fileSize {
+			return block, 0, errTornRecord
+		}
+		return block, 0, errors.New("checksum mismatch")
+	}
+
+	if err := json.Unmarshal(payload, &block); err != nil {
+		if end == fileSize {
+			return block, 0, errTornRecord
+		}
+		return block, 0, fmt.Errorf("decode block: %w", err)
+	}
+	// ✅ Blocks stored before headers were versioned have no Version; their header hashes as version 1
+	if block.Version == 0 {
+		block.Version = BlockVersion1
+	}
+	return block, end, nil
+}
+
+// zeroFrom reports whether the log holds only zero bytes from offset to fileSize
+func (s *BlockStore) zeroFrom(offset, fileSize int64) bool {
+	buf := make([]byte, 64<<10)
+	for offset < fileSize {
+		n, err := s.file.ReadAt(buf[:min(int64(len(buf)), fileSize-offset)], offset)
+		for _, b := range buf[:n] {
+			if b != 0 {
+				return false
+			}
+		}
+		if err != nil && err != io.EOF {
+			return false
+		}
+		if n == 0 {
+			break
+		}
+		offset += int64(n)
+	}
+	return true
+}
+
+// Len returns the number of blocks in the store
+func (s *BlockStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.offsets)
+}
+
+// Append durably writes the next block to the log
+func (s *BlockStore) Append(block Block) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if block.Index != len(s.offsets) {
+		return fmt.Errorf("cannot append block #%d at height %d", block.Index, len(s.offsets))
+	}
+
+	payload, err := json.Marshal(block)
+	if err != nil {
+		return fmt.Errorf("encode block: %w", err)
+	}
+	record := make([]byte, recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	copy(record[recordHeaderSize:], payload)
+
+	// ✅ Write the whole record in one call and fsync before indexing it
+	if _, err := s.file.WriteAt(record, s.size); err != nil {
+		return fmt.Errorf("write block #%d: %w", block.Index, err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("sync block #%d: %w", block.Index, err)
+	}
+
+	s.offsets = append(s.offsets, s.size)
+	s.byHash[block.Hash] = block.Index
+	s.size += int64(len(record))
+	return nil
+}
+
+// BlockAt returns the block stored at the given height
+func (s *BlockStore) BlockAt(height int) (Block, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.blockAt(height)
+}
+
+func (s *BlockStore) blockAt(height int) (Block, error) {
+	if height < 0 || height >= len(s.offsets) {
+		return Block{}, ErrBlockNotFound
+	}
+	block, _, err := s.readRecord(s.offsets[height], s.size)
+	return block, err
+}
+
+// BlockByHash returns the block with the given hash
+func (s *BlockStore) BlockByHash(hash string) (Block, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	height, ok := s.byHash[hash]
+	if !ok {
+		return Block{}, ErrBlockNotFound
+	}
+	return s.blockAt(height)
+}
+
+// LoadChain reads every stored block in height order
+func (s *BlockStore) LoadChain() ([]Block, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chain := make([]Block, 0, len(s.offsets))
+	for height := range s.offsets {
+		block, err := s.blockAt(height)
+		if err != nil {
+			return nil, fmt.Errorf("read block #%d: %w", height, err)
+		}
+		chain = append(chain, block)
+	}
+	return chain, nil
+}
+
+// TruncateTo drops every block at or above the given height
+func (s *BlockStore) TruncateTo(height int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if height < 0 || height >= len(s.offsets) {
+		return nil
+	}
+
+	offset := s.offsets[height]
+	for h := height; h < len(s.offsets); h++ {
+		block, _, err := s.readRecord(s.offsets[h], s.size)
+		if err == nil {
+			delete(s.byHash, block.Hash)
+		}
+	}
+	if err := s.file.Truncate(offset); err != nil {
+		return fmt.Errorf("truncate block log: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("sync block log: %w", err)
+	}
+
+	s.offsets = s.offsets[:height]
+	s.size = offset
+	return nil
+}
+
+// Close closes the underlying log file
+func (s *BlockStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
@@ -0,0 +1,143 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testStore opens a store in a new directory holding blocks 0 to n-1 and returns the
+// directory and the log size
+func testStore(t *testing.T, n int) (string, int64) {
+	t.Helper()
+	dir := t.TempDir()
+	store, err := OpenBlockStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		block := Block{BlockHeader: BlockHeader{Version: BlockVersion1, Index: i}, Hash: fmt.Sprintf("hash-%d", i)}
+		if err := store.Append(block); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(dir, blockLogName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, info.Size()
+}
+
+// testRecord frames a payload like BlockStore.Append
+func testRecord(payload []byte) []byte {
+	record := make([]byte, recordHeaderSize, recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	return append(record, payload...)
+}
+
+func TestOpenBlockStoreTruncatesTornTail(t *testing.T) {
+	tests := []struct {
+		name string
+		tail []byte
+	}{
+		{name: "partial header", tail: []byte{0, 0, 1}},
+		{name: "partial payload", tail: testRecord([]byte(`{"Index":3,"Hash":"hash-3"}`))[:20]},
+		{name: "bad checksum", tail: append([]byte{0, 0, 0, 2, 1, 2, 3, 4}, '{', '}')},
+		{name: "zeroed header", tail: make([]byte, recordHeaderSize)},
+		{name: "zeroed tail", tail: make([]byte, 4096)},
+		{name: "undecodable last record", tail: testRecord([]byte(`{"Index":`))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, size := testStore(t, 3)
+			path := filepath.Join(dir, blockLogName)
+			file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := file.Write(tt.tail); err != nil {
+				t.Fatal(err)
+			}
+			file.Close()
+
+			store, err := OpenBlockStore(dir)
+			if err != nil {
+				t.Fatalf("open: %v", err)
+			}
+			defer store.Close()
+			if store.Len() != 3 {
+				t.Fatalf("%d blocks, expected 3", store.Len())
+			}
+			if info, err := os.Stat(path); err != nil || info.Size() != size {
+				t.Fatalf("log is %d bytes (%v), expected the tail truncated to %d", info.Size(), err, size)
+			}
+
+			// ✅ The next block is appended where the torn record was
+			if err := store.Append(Block{BlockHeader: BlockHeader{Version: BlockVersion1, Index: 3}, Hash: "hash-3"}); err != nil {
+				t.Fatal(err)
+			}
+			if block, err := store.BlockAt(3); err != nil || block.Hash != "hash-3" {
+				t.Fatalf("block #3 is %q (%v)", block.Hash, err)
+			}
+		})
+	}
+}
+
+func TestOpenBlockStoreRefusesCorruption(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(log []byte) []byte
+	}{
+		{
+			name: "bad checksum before the last record",
+			corrupt: func(log []byte) []byte {
+				log[recordHeaderSize] ^= 0xff
+				return log
+			},
+		},
+		{
+			name: "empty record before data",
+			corrupt: func(log []byte) []byte {
+				return append(append(log, make([]byte, recordHeaderSize)...), testRecord([]byte(`{"Index":3}`))...)
+			},
+		},
+		{
+			name: "undecodable record before the last one",
+			corrupt: func(log []byte) []byte {
+				return append(append(log, testRecord([]byte(`{"Index":`))...), testRecord([]byte(`{"Index":4}`))...)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, _ := testStore(t, 3)
+			path := filepath.Join(dir, blockLogName)
+			log, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			corrupted := tt.corrupt(bytes.Clone(log))
+			if err := os.WriteFile(path, corrupted, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			if store, err := OpenBlockStore(dir); err == nil {
+				store.Close()
+				t.Fatal("opened a corrupted block log")
+			}
+			if after, err := os.ReadFile(path); err != nil || !bytes.Equal(after, corrupted) {
+				t.Fatal("a corrupted block log was modified")
+			}
+		})
+	}
+}
@@ -3,28 +3,58 @@ package blockchain
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 )
 
-// Transaction represents a data upload or validation request
+// Transaction is the envelope of every entry in a block: its ID, its type and the payload
+// of that type (see txtypes.go). Exactly one payload field is set, the one Type names.
 type Transaction struct {
-	TxID       string   // Unique transaction ID
-	FileHash   string   // SHA-256 hash of the file
-	Uploader   string   // Public key of uploader
-	Size       int64    // File size in bytes
-	TrustScore float64  // AI-based file quality score
-	Validators []string // List of validators who approved the transaction
-	Signature  string   // Digital signature of uploader
+	TxID      string     // Unique transaction ID
+	Type      TxType     // Selects the payload and the rules the transaction follows
+	Fee       int64      `json:",omitempty"` // QRY its payer pays the block proposer (see fees.go)
+	Approvals []Approval `json:",omitempty"` // Signatures of the validators who approved the transaction (file notarizations only)
+
+	File       *FileNotarization      `json:",omitempty"` // TxFile
+	Transfer   *Transfer              `json:",omitempty"` // TxTransfer
+	Stake      *StakeChange           `json:",omitempty"` // TxStake and TxUnstake
+	Metadata   *MetadataUpdate        `json:",omitempty"` // TxMetadata
+	Revocation *Revocation            `json:",omitempty"` // TxRevocation
+	Coinbase   *Coinbase              `json:",omitempty"` // TxCoinbase
+	Evidence   *DuplicateVoteEvidence `json:",omitempty"` // TxEvidence
+}
+
+// FileNotarization records an uploaded file: its hash and size, who uploaded it and its
+// Proof-of-Data trust score
+type FileNotarization struct {
+	FileHash   string  // SHA-256 hash of the file
+	Uploader   string  // Address of the uploader
+	KeyType    KeyType // Signature scheme of the uploader's key
+	PublicKey  string  // Uploader's public key (hex); must match Uploader
+	Size       int64   // File size in bytes
+	TrustScore float64 // Proof-of-Data score (0-100) from the node's TrustScorer
+	Nonce      uint64  // Uploader-chosen nonce covered by the signature
+	Signature  string  // Uploader's signature over UploadPayload(FileHash, Nonce, Fee)
+
+	TrustFactors []TrustFactor `json:",omitempty"` // How the scoring node arrived at TrustScore; not hashed or sent to peers
 }
 
-// NewTransaction creates a new transaction for an uploaded file
-func NewTransaction(fileHash string, uploader string, size int64, trustScore float64, signature string) Transaction {
+// NewTransaction creates a new transaction for an uploaded file with its trust score (see
+// TrustScorer), paying fee QRY from the uploader's balance
+func NewTransaction(fileHash string, publicKey Verifier, size int64, trustScore float64, nonce uint64, fee int64, signature string) Transaction {
 	tx := Transaction{
-		FileHash:   fileHash,
-		Uploader:   uploader,
-		Size:       size,
-		TrustScore: 70,
-		Signature:  signature,
+		Type: TxFile,
+		Fee:  fee,
+		File: &FileNotarization{
+			FileHash:   fileHash,
+			Uploader:   AddressFromPublicKey(publicKey),
+			KeyType:    publicKey.KeyType(),
+			PublicKey:  EncodePublicKey(publicKey),
+			Size:       size,
+			TrustScore: trustScore,
+			Nonce:      nonce,
+			Signature:  signature,
+		},
 	}
 
 	// Generate a unique transaction ID (TxID)
@@ -32,9 +62,72 @@ func NewTransaction(fileHash string, uploader string, size int64, trustScore flo
 	return tx
 }
 
-// calculateTxID generates a SHA-256 hash as the transaction ID
+// isFile reports whether the transaction notarizes an uploaded file
+func (tx *Transaction) isFile() bool {
+	return tx.Type == TxFile && tx.File != nil
+}
+
+// approvedBy reports whether the validator with the given ID approved the transaction
+func (tx *Transaction) approvedBy(validatorID string) bool {
+	for _, approval := range tx.Approvals {
+		if approval.ValidatorID == validatorID {
+			return true
+		}
+	}
+	return false
+}
+
+// calculateTxID generates the transaction ID: SHA-256 of the canonical transaction encoding
 func (tx *Transaction) calculateTxID() string {
-	input := fmt.Sprintf("%s%s%d%f%s", tx.FileHash, tx.Uploader, tx.Size, tx.TrustScore, tx.Signature)
-	hash := sha256.Sum256([]byte(input))
+	hash := sha256.Sum256(tx.CanonicalBytes())
 	return hex.EncodeToString(hash[:])
 }
+
+// UnmarshalJSON decodes a transaction, including those stored before transactions were
+// typed: they carry no Type, and a file's fields sit in the envelope itself
+func (tx *Transaction) UnmarshalJSON(data []byte) error {
+	type envelope Transaction
+	if err := json.Unmarshal(data, (*envelope)(tx)); err != nil {
+		return err
+	}
+	if tx.Type != "" {
+		return nil
+	}
+
+	switch {
+	case tx.Evidence != nil:
+		tx.Type = TxEvidence
+	case tx.Transfer != nil:
+		tx.Type = TxTransfer
+	case tx.Coinbase != nil:
+		tx.Type = TxCoinbase
+	default:
+		var file FileNotarization
+		if err := json.Unmarshal(data, &file); err != nil {
+			return err
+		}
+		tx.Type, tx.File = TxFile, &file
+	}
+	return nil
+}
+
+// UploadPayload returns the message an uploader signs to submit a file: "<file hash>:<nonce>",
+// or "<file hash>:<nonce>:<fee>" for an upload paying a fee
+func UploadPayload(fileHash string, nonce uint64, fee int64) string {
+	if fee != 0 {
+		return fmt.Sprintf("%s:%d:%d", fileHash, nonce, fee)
+	}
+	return fmt.Sprintf("%s:%d", fileHash, nonce)
+}
+
+// VerifySignature checks that the public key belongs to the uploader address and
+// signed the file hash, nonce and the transaction's fee, so anyone can confirm who
+// submitted the file and what they agreed to pay. The scheme used is the key type
+// recorded in the notarization.
+func (f *FileNotarization) VerifySignature(fee int64) bool {
+	publicKey, err := ParsePublicKey(f.KeyType, f.PublicKey)
+	if err != nil || AddressFromPublicKey(publicKey) != f.Uploader {
+		return false
+	}
+	return VerifySignature(publicKey, UploadPayload(f.FileHash, f.Nonce, fee), f.Signature)
+}
@@ -0,0 +1,296 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"math/bits"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// MinTrustScore is the lowest trust score a file may have to be notarized; validators do not
+// approve blocks holding a file scored lower
+const MinTrustScore = 60.0
+
+// trustSampleBytes bounds how much of a file is read for entropy, format and fingerprinting
+const trustSampleBytes = 1 << 20
+
+// TrustFactor is one component of a trust score, with the reason it scored as it did
+type TrustFactor struct {
+	Name   string  `json:"name"`
+	Score  float64 `json:"score"`  // 0-100; 0 rejects the file whatever the other factors say
+	Weight float64 `json:"weight"` // Share of the total score
+	Detail string  `json:"detail"`
+}
+
+// TrustReport is a file's trust score (0-100) and the factors it was computed from
+type TrustReport struct {
+	Score   float64       `json:"score"`
+	Factors []TrustFactor `json:"factors"`
+}
+
+// TrustInput is what a scorer knows about an uploaded file and the chain it is uploaded to
+type TrustInput struct {
+	Meta     FileMeta // Hash, size and fingerprint of the stored content
+	Sample   []byte   // The first trustSampleBytes of the content
+	Uploader string   // Address of the uploader
+
+	KnownFiles        map[string]bool   // File hashes notarized on chain, or of other files pending in the mempool
+	KnownFingerprints map[string]uint64 // Fingerprints of known files whose content this node stores
+	UploaderFiles     int               // Files the uploader has notarized on chain
+}
+
+// TrustScorer rates uploaded files for Proof-of-Data. Set Blockchain.TrustScorer to use
+// another scoring model.
+type TrustScorer interface {
+	Score(input TrustInput) TrustReport
+}
+
+// ContentTrustScorer is the default scorer. It combines weighted factors computed from the
+// file content and the chain: size, byte entropy, format detection, exact and
+// near-duplicates of known files and the uploader's notarization history.
+type ContentTrustScorer struct{}
+
+// NewContentTrustScorer returns the default scorer
+func NewContentTrustScorer() *ContentTrustScorer {
+	return &ContentTrustScorer{}
+}
+
+// Score rates a file. The total is the weighted sum of the factors, or 0 if any factor is 0.
+func (s *ContentTrustScorer) Score(input TrustInput) TrustReport {
+	mime := http.DetectContentType(input.Sample)
+	factors := []TrustFactor{
+		scoreSize(input.Meta.Size),
+		scoreEntropy(input.Sample, mime),
+		scoreFormat(mime),
+		scoreDuplicate(input.Meta.Hash, input.KnownFiles),
+		scoreNearDuplicate(input.Meta, input.Sample, input.KnownFingerprints),
+		scoreReputation(input.UploaderFiles),
+	}
+
+	total := 0.0
+	for _, factor := range factors {
+		if factor.Score == 0 {
+			total = 0
+			break
+		}
+		total += factor.Score * factor.Weight
+	}
+	return TrustReport{Score: math.Round(total*100) / 100, Factors: factors}
+}
+
+// scoreSize rejects empty files and discounts tiny ones
+func scoreSize(size int64) TrustFactor {
+	factor := TrustFactor{Name: "size", Weight: 0.15, Score: 100, Detail: fmt.Sprintf("%d bytes", size)}
+	switch {
+	case size == 0:
+		factor.Score, factor.Detail = 0, "empty file"
+	case size < 64:
+		factor.Score, factor.Detail = 40, fmt.Sprintf("only %d bytes", size)
+	}
+	return factor
+}
+
+// scoreEntropy rejects constant content, discounts near-constant content (padding) and random-looking content
+// that is not in a compressed format (e.g. encrypted or generated filler)
+func scoreEntropy(sample []byte, mime string) TrustFactor {
+	entropy := ShannonEntropy(sample)
+	factor := TrustFactor{Name: "entropy", Weight: 0.15, Score: 100, Detail: fmt.Sprintf("%.2f bits/byte", entropy)}
+	switch {
+	case len(sample) == 0:
+		factor.Score, factor.Detail = 0, "no content"
+	case entropy < 0.5:
+		factor.Score, factor.Detail = 0, fmt.Sprintf("%.2f bits/byte, content is constant", entropy)
+	case entropy < 1:
+		factor.Score, factor.Detail = 10, fmt.Sprintf("%.2f bits/byte, content is nearly constant", entropy)
+	case entropy > 7.9 && !compressedFormat(mime):
+		factor.Score, factor.Detail = 60, fmt.Sprintf("%.2f bits/byte, looks random or encrypted", entropy)
+	}
+	return factor
+}
+
+// scoreFormat prefers content whose format can be recognized from its first bytes
+func scoreFormat(mime string) TrustFactor {
+	factor := TrustFactor{Name: "format", Weight: 0.15, Score: 100, Detail: mime}
+	if mime == "application/octet-stream" {
+		factor.Score, factor.Detail = 50, "unrecognized binary format"
+	}
+	return factor
+}
+
+// scoreDuplicate rejects a file that is already notarized
+func scoreDuplicate(hash string, known map[string]bool) TrustFactor {
+	if known[hash] {
+		return TrustFactor{Name: "duplicate", Weight: 0.2, Score: 0, Detail: "file is already notarized"}
+	}
+	return TrustFactor{Name: "duplicate", Weight: 0.2, Score: 100, Detail: "new file"}
+}
+
+// scoreNearDuplicate compares the content fingerprint with those of known files: a few
+// differing bits mean a copy with trivial edits
+func scoreNearDuplicate(meta FileMeta, sample []byte, known map[string]uint64) TrustFactor {
+	factor := TrustFactor{Name: "near_duplicate", Weight: 0.2, Score: 100, Detail: "no similar file known"}
+	if len(sample) < fingerprintMinBytes {
+		factor.Detail = "too small to compare"
+		return factor
+	}
+
+	fingerprint := ContentFingerprint(sample)
+	closest, closestHash := 65, ""
+	for hash, other := range known {
+		if hash == meta.Hash {
+			continue
+		}
+		if distance := bits.OnesCount64(fingerprint ^ other); distance < closest {
+			closest, closestHash = distance, hash
+		}
+	}
+	switch {
+	case closest <= 3:
+		factor.Score, factor.Detail = 0, fmt.Sprintf("near copy of %s (%d bits differ)", closestHash, closest)
+	case closest <= 10:
+		factor.Score, factor.Detail = 50, fmt.Sprintf("similar to %s (%d bits differ)", closestHash, closest)
+	}
+	return factor
+}
+
+// scoreReputation starts new uploaders at 50 and adds 10 per file they notarized
+func scoreReputation(files int) TrustFactor {
+	score := 50 + 10*float64(files)
+	if score > 100 {
+		score = 100
+	}
+	return TrustFactor{Name: "reputation", Weight: 0.15, Score: score, Detail: fmt.Sprintf("%d file(s) notarized by uploader", files)}
+}
+
+// compressedFormat reports whether high entropy is expected for a format
+func compressedFormat(mime string) bool {
+	switch {
+	case strings.HasPrefix(mime, "image/") && mime != "image/bmp" && mime != "image/x-icon":
+		return true
+	case strings.HasPrefix(mime, "audio/"), strings.HasPrefix(mime, "video/"):
+		return true
+	}
+	switch mime {
+	case "application/zip", "application/x-gzip", "application/x-rar-compressed", "application/pdf", "font/woff", "font/woff2":
+		return true
+	}
+	return false
+}
+
+// ShannonEntropy returns the entropy of the byte distribution of data, in bits per byte (0-8)
+func ShannonEntropy(data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	var counts [256]int
+	for _, b := range data {
+		counts[b]++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		if count == 0 {
+			continue
+		}
+		p := float64(count) / float64(len(data))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// fingerprintMinBytes is the smallest content a fingerprint is meaningful for
+const fingerprintMinBytes = 64
+
+// ContentFingerprint returns a 64-bit SimHash of the content's 4-byte shingles: similar
+// content has fingerprints that differ in few bits
+func ContentFingerprint(data []byte) uint64 {
+	var weights [64]int
+	for i := 0; i+4 <= len(data); i++ {
+		h := fnv.New64a()
+		h.Write(data[i : i+4])
+		sum := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var fingerprint uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			fingerprint |= 1 << bit
+		}
+	}
+	return fingerprint
+}
+
+// ScoreFile rates a stored file uploaded by uploader with the chain's trust scorer, against
+// the state of the canonical chain and the mempool. Pending uploads of the same file do not
+// count: it may be the one being scored, or one it replaces.
+func (bc *Blockchain) ScoreFile(meta FileMeta, uploader string) (TrustReport, error) {
+	file, err := bc.Files.Open(meta.Hash)
+	if err != nil {
+		return TrustReport{}, err
+	}
+	defer file.Close()
+	sample, err := io.ReadAll(io.LimitReader(file, trustSampleBytes))
+	if err != nil {
+		return TrustReport{}, fmt.Errorf("read file: %w", err)
+	}
+
+	input := TrustInput{
+		Meta:              meta,
+		Sample:            sample,
+		Uploader:          uploader,
+		KnownFiles:        make(map[string]bool),
+		KnownFingerprints: make(map[string]uint64),
+	}
+
+	// ✅ Read the state rather than the chain, so validators can score while voting holds the BFT lock
+	for hash, record := range bc.State().files {
+		input.KnownFiles[hash] = true
+		if record.Uploader == uploader {
+			input.UploaderFiles++
+		}
+	}
+	for _, tx := range bc.Mempool.GetTransactions() {
+		if tx.isFile() && tx.File.FileHash != meta.Hash {
+			input.KnownFiles[tx.File.FileHash] = true
+		}
+	}
+
+	// ✅ Near-duplicates can only be found among files whose content this node stores
+	for hash := range input.KnownFiles {
+		if known, err := bc.Files.Meta(hash); err == nil && known.Fingerprint != "" {
+			if fingerprint, err := strconv.ParseUint(known.Fingerprint, 16, 64); err == nil {
+				input.KnownFingerprints[hash] = fingerprint
+			}
+		}
+	}
+
+	return bc.TrustScorer.Score(input), nil
+}
+
+// RescoreFile scores a file notarization again from the content this node stores, so a
+// transaction cannot carry a trust score its file does not earn. It fails when the node does
+// not store the content.
+func (bc *Blockchain) RescoreFile(file *FileNotarization) (TrustReport, error) {
+	meta, err := bc.Files.Meta(file.FileHash)
+	if errors.Is(err, ErrFileNotFound) {
+		return TrustReport{}, fmt.Errorf("%w: content of file %s is not stored on this node; upload it to be scored", err, file.FileHash)
+	}
+	if err != nil {
+		return TrustReport{}, err
+	}
+	if meta.Size != file.Size {
+		return TrustReport{}, fmt.Errorf("file %s takes %d bytes, not %d", file.FileHash, meta.Size, file.Size)
+	}
+	return bc.ScoreFile(meta, file.Uploader)
+}
@@ -0,0 +1,320 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// TxType selects the payload of a transaction and the rules it follows
+type TxType string
+
+// Transaction types
+const (
+	TxFile       TxType = "file"       // Notarizes an uploaded file (Transaction.File)
+	TxTransfer   TxType = "transfer"   // Moves QRY between accounts (Transaction.Transfer)
+	TxStake      TxType = "stake"      // Bonds QRY to a validator's voting power (Transaction.Stake)
+	TxUnstake    TxType = "unstake"    // Releases bonded QRY (Transaction.Stake)
+	TxMetadata   TxType = "metadata"   // Updates the metadata of a notarized file (Transaction.Metadata)
+	TxRevocation TxType = "revocation" // Revokes a notarized file (Transaction.Revocation)
+	TxCoinbase   TxType = "coinbase"   // Mints the block rewards (Transaction.Coinbase)
+	TxEvidence   TxType = "evidence"   // Convicts a double-signing validator (Transaction.Evidence)
+)
+
+// Sender is the account that signs a transaction of an account type (transfer, stake change,
+// metadata update, revocation). Its nonce orders the account's transactions: each must use the
+// account's nonce + 1, so it applies once and in order.
+type Sender struct {
+	From      string  // Address of the account; must match PublicKey
+	Nonce     uint64  // The account's nonce + 1
+	KeyType   KeyType // Signature scheme of the account's key
+	PublicKey string  // Account's public key (hex)
+	Signature string  // Signature over Transaction.SignBytes
+}
+
+// txType is what a transaction type defines: its encodings, how a transaction of the type
+// is checked on its own and how it changes the chain state
+type txType struct {
+	tag     byte                                        // Tag of the canonical encoding (see encoding.go)
+	kind    byte                                        // Wire kind
+	submit  bool                                        // Clients may submit it; coinbase and evidence are created by nodes
+	payload func(tx *Transaction) bool                  // Whether the type's payload is set
+	sender  func(tx *Transaction) *Sender               // The signing account of account types, else nil
+	check   func(tx *Transaction) error                 // Checks the payload is well-formed
+	verify  func(bc *Blockchain, tx *Transaction) error // Checks the signatures
+	apply   func(state *State, tx Transaction) error    // Applies it to the state; leaves the state unchanged if it fails
+}
+
+// txTypes registers every transaction type. Old types stay here so blocks holding them remain valid.
+var txTypes = map[TxType]txType{
+	TxFile: {
+		tag: tagTransaction, kind: 0, submit: true,
+		payload: func(tx *Transaction) bool { return tx.File != nil },
+		check:   checkFileNotarization,
+		verify: func(bc *Blockchain, tx *Transaction) error {
+			if !tx.File.VerifySignature(tx.Fee) {
+				return errors.New("uploader signature does not match file hash, nonce and fee")
+			}
+			return nil
+		},
+		apply: applyFileNotarization,
+	},
+	TxEvidence: {
+		tag: tagEvidence, kind: 1,
+		payload: func(tx *Transaction) bool { return tx.Evidence != nil },
+		check:   func(tx *Transaction) error { return tx.Evidence.checkConflict() },
+		verify: func(bc *Blockchain, tx *Transaction) error {
+			// ✅ Skipped when the validator set is unknown, e.g. offline
+			if bc.Consensus == nil || bc.Consensus.Validators().Len() == 0 {
+				return nil
+			}
+			return tx.Evidence.Verify(bc.Consensus.Validators())
+		},
+		apply: applyEvidence,
+	},
+	TxTransfer: {
+		tag: tagTransfer, kind: 2, submit: true,
+		payload: func(tx *Transaction) bool { return tx.Transfer != nil },
+		sender:  func(tx *Transaction) *Sender { return &tx.Transfer.Sender },
+		check: func(tx *Transaction) error {
+			if err := ValidateAddress(tx.Transfer.To); err != nil {
+				return fmt.Errorf("recipient: %w", err)
+			}
+			if tx.Transfer.Amount <= 0 {
+				return fmt.Errorf("amount %d is not positive", tx.Transfer.Amount)
+			}
+			return nil
+		},
+		apply: func(state *State, tx Transaction) error {
+			return state.ledger.transfer(*tx.Transfer)
+		},
+	},
+	TxCoinbase: {
+		tag: tagCoinbase, kind: 3,
+		payload: func(tx *Transaction) bool { return tx.Coinbase != nil },
+		check: func(tx *Transaction) error {
+			for _, payout := range tx.Coinbase.Payouts {
+				if payout.Amount <= 0 {
+					return fmt.Errorf("payout of %d QRY to %s is not positive", payout.Amount, payout.Address)
+				}
+			}
+			return nil
+		},
+		apply: func(state *State, tx Transaction) error {
+			state.ledger.mint(tx.Coinbase.Payouts)
+			return nil
+		},
+	},
+	TxStake: {
+		tag: tagStake, kind: 4, submit: true,
+		payload: func(tx *Transaction) bool { return tx.Stake != nil },
+		sender:  func(tx *Transaction) *Sender { return &tx.Stake.Sender },
+		check:   checkStakeChange,
+		apply:   applyStake,
+	},
+	TxUnstake: {
+		tag: tagUnstake, kind: 5, submit: true,
+		payload: func(tx *Transaction) bool { return tx.Stake != nil },
+		sender:  func(tx *Transaction) *Sender { return &tx.Stake.Sender },
+		check:   checkStakeChange,
+		apply:   applyUnstake,
+	},
+	TxMetadata: {
+		tag: tagMetadata, kind: 6, submit: true,
+		payload: func(tx *Transaction) bool { return tx.Metadata != nil },
+		sender:  func(tx *Transaction) *Sender { return &tx.Metadata.Sender },
+		check:   checkMetadataUpdate,
+		apply:   applyMetadataUpdate,
+	},
+	TxRevocation: {
+		tag: tagRevocation, kind: 7, submit: true,
+		payload: func(tx *Transaction) bool { return tx.Revocation != nil },
+		sender:  func(tx *Transaction) *Sender { return &tx.Revocation.Sender },
+		check:   checkRevocation,
+		apply:   applyRevocation,
+	},
+}
+
+// txTypeOfKind returns the transaction type with the given wire kind
+func txTypeOfKind(kind byte) (TxType, bool) {
+	for name, t := range txTypes {
+		if t.kind == kind {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Sender returns the account that signed the transaction, or nil if it is not of an account type
+func (tx *Transaction) Sender() *Sender {
+	t, ok := txTypes[tx.Type]
+	if !ok || t.sender == nil || !t.payload(tx) {
+		return nil
+	}
+	return t.sender(tx)
+}
+
+// checkFormat checks a transaction on its own: a known type carrying exactly its payload, an
+// ID matching the contents and a well-formed payload
+func (tx *Transaction) checkFormat() error {
+	t, ok := txTypes[tx.Type]
+	if !ok {
+		return fmt.Errorf("unknown transaction type %q", tx.Type)
+	}
+	payloads := 0
+	for _, set := range []bool{tx.File != nil, tx.Transfer != nil, tx.Stake != nil, tx.Metadata != nil, tx.Revocation != nil, tx.Coinbase != nil, tx.Evidence != nil} {
+		if set {
+			payloads++
+		}
+	}
+	if payloads != 1 || !t.payload(tx) {
+		return fmt.Errorf("a %s transaction must carry exactly its own payload", tx.Type)
+	}
+	if tx.Type != TxFile && len(tx.Approvals) > 0 {
+		return fmt.Errorf("a %s transaction carries no validator approvals", tx.Type)
+	}
+	if tx.Fee < 0 {
+		return fmt.Errorf("fee %d is negative", tx.Fee)
+	}
+	if tx.Fee > 0 && tx.Payer() == "" {
+		return fmt.Errorf("a %s transaction pays no fee", tx.Type)
+	}
+	if tx.calculateTxID() != tx.TxID {
+		return errors.New("transaction ID does not match contents")
+	}
+	return t.check(tx)
+}
+
+// verifySignatures checks the signatures of a well-formed transaction: the sender's for
+// account types, and whatever else the type is signed with
+func (bc *Blockchain) verifySignatures(tx *Transaction) error {
+	t := txTypes[tx.Type]
+	if sender := tx.Sender(); sender != nil {
+		if err := sender.verify(tx.SignBytes()); err != nil {
+			return err
+		}
+	}
+	if t.verify != nil {
+		return t.verify(bc, tx)
+	}
+	return nil
+}
+
+// verify checks that the public key belongs to the sender address and signed the message
+func (s Sender) verify(message []byte) error {
+	publicKey, err := ParsePublicKey(s.KeyType, s.PublicKey)
+	if err != nil {
+		return fmt.Errorf("invalid sender public key: %w", err)
+	}
+	if AddressFromPublicKey(publicKey) != s.From {
+		return errors.New("public key does not belong to the sender address")
+	}
+	if !VerifySignature(publicKey, string(message), s.Signature) {
+		return errors.New("invalid sender signature")
+	}
+	return nil
+}
+
+// SignTransaction signs a transaction of an account type with the sender's wallet, as the
+// account's transaction with the given nonce, and sets its ID
+func SignTransaction(wallet *Wallet, tx *Transaction, nonce uint64) error {
+	sender := tx.Sender()
+	if sender == nil {
+		return fmt.Errorf("%s transactions are not signed by an account", tx.Type)
+	}
+	*sender = Sender{
+		From:      wallet.Address(),
+		Nonce:     nonce,
+		KeyType:   wallet.KeyType(),
+		PublicKey: wallet.PublicKeyHex(),
+	}
+	signature, err := wallet.SignData(string(tx.SignBytes()))
+	if err != nil {
+		return err
+	}
+	sender.Signature = signature
+	tx.TxID = tx.calculateTxID()
+	return nil
+}
+
+// NewSignedTransaction sets the ID of a transaction its client built and signed
+func NewSignedTransaction(tx Transaction) Transaction {
+	tx.TxID = tx.calculateTxID()
+	return tx
+}
+
+// ============================
+// File notarization
+// ============================
+
+// checkFileNotarization checks a file's hash, size and trust score
+func checkFileNotarization(tx *Transaction) error {
+	file := tx.File
+	if err := checkFileHash(file.FileHash); err != nil {
+		return err
+	}
+	if file.Size <= 0 {
+		return fmt.Errorf("file size %d is not positive", file.Size)
+	}
+	if file.TrustScore < 0 || file.TrustScore > 100 {
+		return fmt.Errorf("trust score %.2f is not between 0 and 100", file.TrustScore)
+	}
+	return nil
+}
+
+// checkFileHash checks that a file hash is a hex SHA-256
+func checkFileHash(fileHash string) error {
+	if hash, err := hex.DecodeString(fileHash); err != nil || len(hash) != 32 {
+		return fmt.Errorf("file hash %q is not a hex SHA-256", fileHash)
+	}
+	return nil
+}
+
+// applyFileNotarization records a file in the registry; a file can only be notarized once.
+// From block version 6 on, an uploader can use each upload nonce once, so a signed upload
+// cannot be replayed.
+func applyFileNotarization(state *State, tx Transaction) error {
+	file := tx.File
+	if _, ok := state.files[file.FileHash]; ok {
+		return fmt.Errorf("duplicate file hash %s", file.FileHash)
+	}
+	if state.rules().UploadNonces {
+		if state.uploads[file.Uploader][file.Nonce] {
+			return fmt.Errorf("uploader %s already used nonce %d", file.Uploader, file.Nonce)
+		}
+		if state.uploads[file.Uploader] == nil {
+			state.uploads[file.Uploader] = make(map[uint64]bool)
+		}
+		state.uploads[file.Uploader][file.Nonce] = true
+	}
+	state.files[file.FileHash] = &FileRecord{
+		FileHash: file.FileHash,
+		Uploader: file.Uploader,
+		Height:   state.height,
+		TxID:     tx.TxID,
+	}
+	return nil
+}
+
+// ============================
+// Evidence
+// ============================
+
+// applyEvidence convicts a validator once and slashes slash_percent % of its stake from its
+// bonded QRY, then its balance (only from its balance before block version 7)
+func applyEvidence(state *State, tx Transaction) error {
+	id := tx.Evidence.VoteA.ValidatorID
+	v, ok := state.validators[id]
+	if !ok {
+		return fmt.Errorf("unknown validator %s", id)
+	}
+	if v.Jailed {
+		return fmt.Errorf("validator %s is already convicted", id)
+	}
+	v.Jailed, v.JailedAt = true, state.height
+
+	// ✅ Slashing is replayed from the chain like every other balance change
+	stake := v.Stake + state.ledger.Account(v.Address).Bonded
+	state.ledger.slash(v.Address, stake*int64(state.params.SlashPercent)/100, state.rules().BondSlashing)
+	return nil
+}
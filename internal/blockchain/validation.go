@@ -0,0 +1,292 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ValidateChain checks a complete chain from genesis: hashes, links, index continuity,
+// block and transaction signatures, state transitions and roots, and commit certificates
+func (bc *Blockchain) ValidateChain(chain []Block) error {
+	if len(chain) == 0 {
+		return errors.New("chain is empty")
+	}
+	if err := bc.validateGenesis(chain[0]); err != nil {
+		return fmt.Errorf("genesis block: %w", err)
+	}
+
+	state := NewState(bc.Genesis)
+	for i := 1; i < len(chain); i++ {
+		if err := bc.validateBlock(chain[i], chain[i-1], state); err != nil {
+			return fmt.Errorf("block #%d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// ValidateBlock checks a block whose parent is in the block tree against the state after
+// the parent, and returns the state after the block
+func (bc *Blockchain) ValidateBlock(block Block) (*State, error) {
+	if block.Index == 0 {
+		if err := bc.validateGenesis(block); err != nil {
+			return nil, err
+		}
+		return NewState(bc.Genesis), nil
+	}
+
+	parent, ok := bc.Tree.Get(block.PreviousHash)
+	if !ok {
+		return nil, fmt.Errorf("block #%d: %w", block.Index, ErrUnknownParent)
+	}
+	state, err := bc.stateAfter(parent.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if err := bc.validateBlock(block, parent, state); err != nil {
+		return nil, fmt.Errorf("block #%d: %w", block.Index, err)
+	}
+	return state, nil
+}
+
+// validateProposedBlock checks a block proposed for voting on top of the canonical head;
+// it has no commit certificate yet, and its proposer is checked against the voting round
+// (see checkProposer)
+func (bc *Blockchain) validateProposedBlock(block Block) error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	return bc.validateBlockContents(block, bc.Chain[len(bc.Chain)-1], bc.State().Clone())
+}
+
+// validateGenesis checks that the first block of a chain is the genesis block of our genesis file
+func (bc *Blockchain) validateGenesis(block Block) error {
+	if bc.Genesis == nil {
+		return errors.New("no genesis file to check against")
+	}
+	expected := bc.Genesis.Block()
+	if block.Index != 0 {
+		return fmt.Errorf("genesis index is %d", block.Index)
+	}
+	if len(block.Transactions) != 0 || block.Commit != nil || len(block.Approvals) != 0 || block.Signature != "" {
+		return errors.New("genesis must not contain transactions, a commit, approvals or a signature")
+	}
+
+	hash, err := block.ComputeHash()
+	if err != nil {
+		return err
+	}
+	if hash != block.Hash || block.Hash != expected.Hash {
+		return fmt.Errorf("genesis hash %s does not match genesis file (%s)", block.Hash, expected.Hash)
+	}
+	return nil
+}
+
+// validateBlock checks a block and its consensus data against its parent; state is the
+// state after the parent and is updated with the block
+func (bc *Blockchain) validateBlock(block Block, prev Block, state *State) error {
+	// ✅ The consensus engine checks the commit against the validators of the parent's state
+	// (skipped when the validator set is unknown, e.g. offline)
+	if bc.Consensus != nil && bc.Consensus.Validators().Len() > 0 {
+		if err := bc.Consensus.ValidateProposal(state, block); err != nil {
+			return err
+		}
+	}
+
+	// ✅ A committed block may come from the proposer of any round up to its commit's
+	lastRound := 0
+	if block.Commit != nil {
+		lastRound = block.Commit.Round
+	}
+	if err := bc.checkProposer(block, prev.Hash, state, lastRound); err != nil {
+		return err
+	}
+	return bc.validateBlockContents(block, prev, state)
+}
+
+// checkProposer checks that a block on top of prevHash comes from the validator scheduled
+// in one of the voting rounds up to lastRound; state is the state after the parent (skipped
+// before the scheduled-proposer rules and when the validator set is unknown)
+func (bc *Blockchain) checkProposer(block Block, prevHash string, state *State, lastRound int) error {
+	if !bc.Config.RulesAt(block.Index).ScheduledProposer || bc.Consensus == nil || bc.Consensus.Validators().Len() == 0 {
+		return nil
+	}
+
+	// ✅ Every active validator proposes once in as many rounds, so later rounds add no one
+	active := len(bc.Consensus.Validators().Active(state))
+	for round := 0; round <= lastRound && round < active; round++ {
+		expected := bc.Consensus.SelectProposer(state, prevHash, round)
+		if expected == nil {
+			break
+		}
+		if expected.Address == block.Proposer {
+			return nil
+		}
+	}
+	// ✅ With every validator jailed, no block can follow the parent
+	if active == 0 {
+		return errors.New("no validator can propose: every validator is jailed")
+	}
+	return fmt.Errorf("proposed by %s, which is not scheduled in rounds 0 to %d", block.Proposer, lastRound)
+}
+
+// validateBlockContents checks everything about a block but its consensus data and proposer, and applies
+// it to state, the state after the parent
+func (bc *Blockchain) validateBlockContents(block Block, prev Block, state *State) error {
+	if block.Index != prev.Index+1 {
+		return fmt.Errorf("index %d does not follow %d", block.Index, prev.Index)
+	}
+	if block.PreviousHash != prev.Hash {
+		return errors.New("previous hash does not match parent block")
+	}
+	if len(block.Transactions) == 0 {
+		return errors.New("block contains no transactions")
+	}
+	if err := bc.validateBlockHeader(block); err != nil {
+		return err
+	}
+
+	size := 0
+	for _, tx := range block.Transactions {
+		size += tx.Size()
+	}
+	if size > bc.Config.Params.MaxBlockBytes {
+		return fmt.Errorf("transactions take %d bytes, limit is %d", size, bc.Config.Params.MaxBlockBytes)
+	}
+
+	for i, tx := range block.Transactions {
+		if err := bc.validateTransaction(tx, i, block, prev); err != nil {
+			return fmt.Errorf("transaction %s: %w", tx.TxID, err)
+		}
+	}
+	if err := state.applyBlock(block); err != nil {
+		return err
+	}
+
+	// ✅ Every node that applies the block must reach the state its proposer committed to
+	if bc.Config.RulesAt(block.Index).StateRoot {
+		if root := state.Root(); block.StateRoot != root {
+			return fmt.Errorf("state root %s does not match the state after the block (%s)", block.StateRoot, root)
+		}
+	}
+
+	// ✅ A block owing rewards must carry its coinbase (skipped when the validator set is unknown)
+	if bc.Consensus != nil && bc.Consensus.Validators().Len() > 0 && block.Transactions[0].Coinbase == nil {
+		if payouts := bc.Consensus.Rewards(prev, blockSigner(block)); len(payouts) > 0 {
+			return errors.New("block has no coinbase for its rewards")
+		}
+	}
+	return nil
+}
+
+// blockSigner returns the address of the key that signed a block (its proposer)
+func blockSigner(block Block) string {
+	publicKey, err := ParsePublicKey(block.KeyType, block.PublicKey)
+	if err != nil {
+		return ""
+	}
+	return AddressFromPublicKey(publicKey)
+}
+
+// validateTransaction checks a transaction at position i of a block with the rules of its
+// type, and where it may appear: a coinbase comes first, evidence is for votes up to the
+// block's height and approvals come from registered validators
+func (bc *Blockchain) validateTransaction(tx Transaction, i int, block Block, prev Block) error {
+	if err := tx.checkFormat(); err != nil {
+		return err
+	}
+	if err := bc.verifySignatures(&tx); err != nil {
+		return err
+	}
+	if tx.Fee > 0 && !bc.Config.RulesAt(block.Index).Fees {
+		return fmt.Errorf("block version %d does not allow fees", block.Version)
+	}
+
+	switch tx.Type {
+	case TxCoinbase:
+		return bc.validateCoinbase(tx, i, block, prev)
+	case TxEvidence:
+		if tx.Evidence.VoteA.Height > block.Index {
+			return fmt.Errorf("votes for height %d cannot be convicted at height %d", tx.Evidence.VoteA.Height, block.Index)
+		}
+	case TxFile:
+		return bc.validateApprovals(tx)
+	}
+	return nil
+}
+
+// validateCoinbase checks a coinbase at position i of a block: it must come first, be for the
+// block's height and pay exactly the rewards of the consensus engine
+func (bc *Blockchain) validateCoinbase(tx Transaction, i int, block Block, prev Block) error {
+	if i != 0 {
+		return errors.New("coinbase is not the first transaction")
+	}
+	if tx.Coinbase.Height != block.Index {
+		return fmt.Errorf("coinbase for height %d in block #%d", tx.Coinbase.Height, block.Index)
+	}
+
+	// ✅ Skipped when the validator set is unknown, e.g. offline
+	if bc.Consensus != nil && bc.Consensus.Validators().Len() > 0 {
+		if expected := bc.Consensus.Rewards(prev, blockSigner(block)); !samePayouts(tx.Coinbase.Payouts, expected) {
+			return fmt.Errorf("coinbase pays %v, rewards are %v", tx.Coinbase.Payouts, expected)
+		}
+	}
+	return nil
+}
+
+// validateBlockHeader checks the header against the rules active at the block's height,
+// recomputes the Merkle root and hash and verifies the block signature
+func (bc *Blockchain) validateBlockHeader(block Block) error {
+	// ✅ Old blocks keep being checked with the rules that were active at their height
+	rules := bc.Config.RulesAt(block.Index)
+	if block.Version != rules.Version {
+		return fmt.Errorf("block version %d, rules at height %d require version %d", block.Version, block.Index, rules.Version)
+	}
+	if !rules.StateRoot && block.StateRoot != "" {
+		return fmt.Errorf("version %d blocks carry no state root", block.Version)
+	}
+
+	if MerkleRoot(block.Transactions) != block.MerkleRoot {
+		return errors.New("merkle root does not match transactions")
+	}
+	hash, err := block.ComputeHash()
+	if err != nil {
+		return err
+	}
+	if hash != block.Hash {
+		return errors.New("hash does not match block contents")
+	}
+
+	publicKey, err := ParsePublicKey(block.KeyType, block.PublicKey)
+	if err != nil {
+		return fmt.Errorf("invalid signer public key: %w", err)
+	}
+	if !VerifySignature(publicKey, block.Hash, block.Signature) {
+		return errors.New("invalid block signature")
+	}
+	return rules.check(block, AddressFromPublicKey(publicKey), bc.Config)
+}
+
+// validateApprovals checks that a file's approvals are signatures over its TxID by distinct
+// registered validators (skipped when the set is unknown, e.g. offline)
+func (bc *Blockchain) validateApprovals(tx Transaction) error {
+	if bc.Consensus == nil || bc.Consensus.Validators().Len() == 0 {
+		return nil
+	}
+	seen := make(map[string]bool)
+	for _, approval := range tx.Approvals {
+		if seen[approval.ValidatorID] {
+			return fmt.Errorf("duplicate approval from validator %s", approval.ValidatorID)
+		}
+		seen[approval.ValidatorID] = true
+
+		validator := bc.Consensus.Validators().ByID(approval.ValidatorID)
+		if validator == nil {
+			return fmt.Errorf("approval from unknown validator %s", approval.ValidatorID)
+		}
+		publicKey, err := ParsePublicKey(validator.KeyType, validator.PublicKey)
+		if err != nil || !VerifySignature(publicKey, tx.TxID, approval.Signature) {
+			return fmt.Errorf("invalid approval signature from validator %s", approval.ValidatorID)
+		}
+	}
+	return nil
+}
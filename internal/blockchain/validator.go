@@ -1,116 +1,191 @@
 package blockchain
 
 import (
-	"crypto/ecdsa"
-	"crypto/elliptic"
-	"crypto/rand"
-	"crypto/sha256"
 	"crypto/x509"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 )
 
+// validatorKeyName is the file holding this node's validator key inside the data directory
+const validatorKeyName = "validator_key.json"
+
+// validatorKeyFile is the on-disk form of a validator key (PKCS#8, hex). It is unencrypted
+// so the node can sign approvals unattended, and is written with owner-only permissions.
+type validatorKeyFile struct {
+	ID         string `json:"id"`
+	PrivateKey string `json:"private_key"`
+}
+
 // Validator represents a network participant who verifies transactions & blocks
 type Validator struct {
-	ID         string             `json:"ID"`
-	Balance    int                `json:"Balance"`
-	PrivateKey *ecdsa.PrivateKey  `json:"-"` // 🚨 Exclude from JSON
-	PublicKey  string             `json:"PublicKey"`
+	ID        string  `json:"ID"`
+	Stake     int64   `json:"Stake"`     // Stake assigned in the genesis file; see State for jailing and bonded stake
+	Signer    Signer  `json:"-"`         // 🚨 Private key, excluded from JSON
+	KeyType   KeyType `json:"KeyType"`   // Signature scheme of the validator key
+	PublicKey string  `json:"PublicKey"` // Public key (hex)
+	Address   string  `json:"Address"`   // Checksummed address of PublicKey
 }
 
-// NewValidator creates a new validator with a unique key pair
+// NewValidator creates a new validator with a unique P-256 key pair
 func NewValidator(id string) *Validator {
-	privKey, pubKeyHex := generateKeyPair()
-	return &Validator{
-		ID:         id,
-		Balance:    0, // Validators start with zero rewards
-		PrivateKey: privKey,
-		PublicKey:  pubKeyHex,
-	}
-}
-
-// generateKeyPair creates an ECDSA key pair
-func generateKeyPair() (*ecdsa.PrivateKey, string) {
-	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	signer, err := GenerateSigner(KeyTypeP256)
 	if err != nil {
 		fmt.Println("❌ Error generating validator key:", err)
-		return nil, ""
+		return &Validator{ID: id}
 	}
-
-	// Convert Public Key to a Hex string
-	pubKeyBytes, _ := x509.MarshalPKIXPublicKey(&privKey.PublicKey)
-	pubKeyHex := hex.EncodeToString(pubKeyBytes)
-
-	return privKey, pubKeyHex
+	return NewValidatorWithSigner(id, signer)
 }
 
-// ApproveBlock verifies the integrity of a block before it's added to the blockchain
-func (v *Validator) ApproveBlock(block Block) bool {
-	// ✅ Simulate AI-based trust score (must be at least 60% to approve)
-	trustScore := v.CalculateTrustScore()
-	if trustScore >= 60.0 {
-		fmt.Printf("❌ Validator %s: Block #%d trust score too low (%.2f)! Block rejected.\n", v.ID, block.Index, trustScore)
-		return false
+// NewValidatorWithSigner creates a validator that signs with an existing key of any supported type
+func NewValidatorWithSigner(id string, signer Signer) *Validator {
+	publicKey := signer.Public()
+	return &Validator{
+		ID:        id,
+		Signer:    signer,
+		KeyType:   publicKey.KeyType(),
+		PublicKey: EncodePublicKey(publicKey),
+		Address:   AddressFromPublicKey(publicKey),
 	}
+}
 
-	// ✅ Ensure the block hash is valid (simulate proof-of-data verification)
-	blockHash := sha256.Sum256([]byte(block.Hash))
-	if blockHash[len(blockHash)-1]%2 != 0 { // ✅ Arbitrary rule: hash must end in even number
-		fmt.Printf("❌ Validator %s: Block #%d hash is invalid! Block rejected.\n", v.ID, block.Index)
-		return false
+// ApproveBlock verifies the integrity of a proposed block; a validator only prevotes
+// for blocks it approves. Files whose content this node stores are scored again; for the
+// others the validator relies on the recorded score if validators signed approvals of them
+// (checked with the block, see validateApprovals).
+func (v *Validator) ApproveBlock(block *Block, blockchain *Blockchain) bool {
+	// ✅ Every file in the block must have a Proof-of-Data trust score of at least MinTrustScore
+	lowest := 100.0
+	for _, tx := range block.Transactions {
+		if !tx.isFile() {
+			continue
+		}
+		score := tx.File.TrustScore
+		if report, err := blockchain.RescoreFile(tx.File); err == nil {
+			score = report.Score
+		} else if !errors.Is(err, ErrFileNotFound) {
+			fmt.Printf("❌ Validator %s: Block #%d holds file %s that cannot be scored (%v)! Block rejected.\n", v.ID, block.Index, tx.File.FileHash, err)
+			return false
+		} else if len(tx.Approvals) == 0 {
+			fmt.Printf("❌ Validator %s: Block #%d holds file %s that no validator approved! Block rejected.\n", v.ID, block.Index, tx.File.FileHash)
+			return false
+		}
+		if score < MinTrustScore {
+			fmt.Printf("❌ Validator %s: Block #%d holds file %s with trust score too low (%.2f)! Block rejected.\n", v.ID, block.Index, tx.File.FileHash, score)
+			return false
+		}
+		if score < lowest {
+			lowest = score
+		}
 	}
 
 	// ✅ Block approved
-	fmt.Printf("✅ Validator %s: Approved Block #%d (Trust Score: %.2f)\n", v.ID, block.Index, trustScore)
-
-	// ✅ Validator digitally signs the block
-	v.SignBlock(&block)
+	fmt.Printf("✅ Validator %s: Approved Block #%d (Lowest Trust Score: %.2f)\n", v.ID, block.Index, lowest)
 
 	return true
 }
 
-// ValidateTransaction verifies if a transaction is legitimate
-func (v *Validator) ValidateTransaction(tx Transaction, blockchain *Blockchain) bool {
+// ValidateTransaction verifies if a transaction is legitimate and, if so, adds the
+// validator's approval to it
+func (v *Validator) ValidateTransaction(tx *Transaction, blockchain *Blockchain) error {
+	if !tx.isFile() {
+		return fmt.Errorf("validators only approve file notarizations, not %s transactions", tx.Type)
+	}
+	file := tx.File
+
 	// ✅ Check if file hash already exists in blockchain (prevent duplicates)
-	for _, block := range blockchain.Chain {
-		for _, txn := range block.Transactions {
-			if txn.FileHash == tx.FileHash {
-				fmt.Printf("❌ Validator %s: Duplicate file detected! Transaction rejected.\n", v.ID)
-				return false
-			}
-		}
+	if _, err := blockchain.FileRecord(file.FileHash); err == nil {
+		fmt.Printf("❌ Validator %s: Duplicate file detected! Transaction rejected.\n", v.ID)
+		return fmt.Errorf("duplicate file %s", file.FileHash)
 	}
 
-	// ✅ Assign an AI-based trust score (minimum 50% required)
-	trustScore := v.CalculateTrustScore()
-	if trustScore < 50.0 {
-		fmt.Printf("❌ Validator %s: File trust score too low (%.2f)! Transaction rejected.\n", v.ID, trustScore)
-		return false
+	// ✅ The file's Proof-of-Data trust score, scored again from its content, must be at least MinTrustScore
+	report, err := blockchain.RescoreFile(file)
+	if err != nil {
+		fmt.Printf("❌ Validator %s: File cannot be scored (%v)! Transaction rejected.\n", v.ID, err)
+		return err
+	}
+	if report.Score < MinTrustScore {
+		fmt.Printf("❌ Validator %s: File trust score too low (%.2f)! Transaction rejected.\n", v.ID, report.Score)
+		return fmt.Errorf("trust score %.2f is too low", report.Score)
 	}
 
-	// ✅ Approve transaction
-	tx.Validators = append(tx.Validators, v.PublicKey)
-	fmt.Printf("✅ Validator %s: Approved transaction %s (Trust Score: %.2f)\n", v.ID, tx.TxID, trustScore)
+	// ✅ Approve transaction by signing its TxID
+	if !tx.approvedBy(v.ID) {
+		if v.Signer == nil {
+			return errors.New("validator has no private key")
+		}
+		signature, err := (&Wallet{Signer: v.Signer}).SignData(tx.TxID)
+		if err != nil {
+			return err
+		}
+		tx.Approvals = append(tx.Approvals, Approval{ValidatorID: v.ID, Signature: signature})
+	}
+	fmt.Printf("✅ Validator %s: Approved transaction %s (Trust Score: %.2f)\n", v.ID, tx.TxID, report.Score)
 
-	return true
+	return nil
+}
+
+// SignVote signs a prevote or precommit with the validator's private key
+func (v *Validator) SignVote(vote *Vote) error {
+	if v.Signer == nil {
+		return errors.New("validator has no private key")
+	}
+	vote.ValidatorID = v.ID
+
+	wallet := &Wallet{Signer: v.Signer}
+	signature, err := wallet.SignData(string(vote.SignBytes()))
+	if err != nil {
+		return err
+	}
+	vote.Signature = signature
+	return nil
 }
 
-// CalculateTrustScore simulates an AI-based scoring model for validation
-func (v *Validator) CalculateTrustScore() float64 {
-	// ✅ Simulate AI model with a random score between 50-100
-	return 50.0 + 1
+// VerifyVote checks a vote signature against the validator's registered public key
+func (v *Validator) VerifyVote(vote Vote) bool {
+	publicKey, err := ParsePublicKey(v.KeyType, v.PublicKey)
+	if err != nil {
+		return false
+	}
+	return vote.ValidatorID == v.ID && VerifySignature(publicKey, string(vote.SignBytes()), vote.Signature)
 }
 
-// RewardValidator increases the validator’s balance upon successful validation
-func (v *Validator) RewardValidator(amount int) {
-	v.Balance += amount
-	fmt.Printf("💰 Validator %s earned %d QRY tokens! New Balance: %d\n", v.ID, amount, v.Balance)
+// SaveValidatorKey writes this node's validator key to the data directory
+func SaveValidatorKey(dataDir string, id string, signer Signer) error {
+	der, err := x509.MarshalPKCS8PrivateKey(signer.PrivateKey())
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(validatorKeyFile{ID: id, PrivateKey: hex.EncodeToString(der)}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dataDir, validatorKeyName), data, 0o600)
 }
 
-// SignBlock digitally signs a block with the validator's private key
-func (v *Validator) SignBlock(block *Block) {
-	blockHash := sha256.Sum256([]byte(block.Hash))
-	signature := hex.EncodeToString(blockHash[:]) // Simulate signature
-	block.Signature = signature
-	fmt.Printf("✍️ Validator %s signed Block #%d\n", v.ID, block.Index)
+// LoadValidatorKey reads this node's validator key; the error wraps os.ErrNotExist
+// when the node is not a validator
+func LoadValidatorKey(dataDir string) (string, Signer, error) {
+	data, err := os.ReadFile(filepath.Join(dataDir, validatorKeyName))
+	if err != nil {
+		return "", nil, err
+	}
+
+	var file validatorKeyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return "", nil, fmt.Errorf("decode validator key: %w", err)
+	}
+	der, err := hex.DecodeString(file.PrivateKey)
+	if err != nil {
+		return "", nil, fmt.Errorf("validator key is not hex: %w", err)
+	}
+	signer, err := parsePrivateKey(der)
+	if err != nil {
+		return "", nil, err
+	}
+	return file.ID, signer, nil
 }
@@ -1,62 +1,89 @@
 package blockchain
 
 import (
-	"crypto/ecdsa"
-	"crypto/elliptic"
-	"crypto/rand"
-	"crypto/sha256"
-	"encoding/base64"
 	"fmt"
-	"math/big"
-	"strings"
+	"strconv"
 )
 
-// Wallet represents a user's private and public keys
+// Wallet represents a user's signing key
 type Wallet struct {
-	PrivateKey *ecdsa.PrivateKey
-	PublicKey  *ecdsa.PublicKey
+	Signer Signer `json:"-"` // 🚨 Never serialize the private key
 }
 
-// NewWallet generates a new wallet with an ECDSA key pair
+// NewWallet generates a new wallet with a P-256 ECDSA key pair
 func NewWallet() *Wallet {
-	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	wallet, err := NewWalletOfType(KeyTypeP256)
 	if err != nil {
 		fmt.Println("❌ Error generating key pair:", err)
 		return nil
 	}
-	return &Wallet{PrivateKey: privateKey, PublicKey: &privateKey.PublicKey}
+	return wallet
 }
 
-// SignData signs a given hash using the private key
-func (w *Wallet) SignData(data string) (string, error) {
-	hash := sha256.Sum256([]byte(data))
-	r, s, err := ecdsa.Sign(rand.Reader, w.PrivateKey, hash[:])
+// NewWalletOfType generates a new wallet using the given signature scheme
+func NewWalletOfType(keyType KeyType) (*Wallet, error) {
+	signer, err := GenerateSigner(keyType)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	return &Wallet{Signer: signer}, nil
+}
+
+// KeyType returns the wallet's signature scheme
+func (w *Wallet) KeyType() KeyType {
+	return w.Signer.Public().KeyType()
+}
 
-	// Convert r and s to base64 for easy storage
-	rText := base64.StdEncoding.EncodeToString(r.Bytes())
-	sText := base64.StdEncoding.EncodeToString(s.Bytes())
+// PublicKey returns the wallet's public key
+func (w *Wallet) PublicKey() Verifier {
+	return w.Signer.Public()
+}
 
-	return fmt.Sprintf("%s.%s", rText, sText), nil
+// PublicKeyHex returns the wallet's public key as hex of its canonical bytes
+func (w *Wallet) PublicKeyHex() string {
+	return EncodePublicKey(w.Signer.Public())
 }
 
-// VerifySignature verifies a signed transaction or block
-func VerifySignature(publicKey *ecdsa.PublicKey, data string, signature string) bool {
-	hash := sha256.Sum256([]byte(data))
+// Address returns the wallet's checksummed address, which is also its keystore ID
+func (w *Wallet) Address() string {
+	return AddressFromPublicKey(w.Signer.Public())
+}
 
-	// Split the signature into r and s components
-	parts := strings.Split(signature, ".")
-	if len(parts) != 2 {
-		return false
+// SignData signs the given data and returns a key-type tagged signature
+func (w *Wallet) SignData(data string) (string, error) {
+	signature, err := w.Signer.Sign([]byte(data))
+	if err != nil {
+		return "", err
 	}
+	return EncodeSignature(w.KeyType(), signature), nil
+}
 
-	rBytes, _ := base64.StdEncoding.DecodeString(parts[0])
-	sBytes, _ := base64.StdEncoding.DecodeString(parts[1])
+// messagePrefix starts everything a wallet signs as a message, so a signed message can never
+// pass for a transaction, upload, approval or vote signature
+const messagePrefix = "pod-signed-message:"
 
-	r := new(big.Int).SetBytes(rBytes)
-	s := new(big.Int).SetBytes(sBytes)
+// MessageSignBytes returns the bytes a wallet signs for a message: the prefix, the message's
+// length and the message
+func MessageSignBytes(message string) string {
+	return messagePrefix + strconv.Itoa(len(message)) + ":" + message
+}
+
+// SignMessage signs a message of the caller's choosing (see MessageSignBytes)
+func (w *Wallet) SignMessage(message string) (string, error) {
+	return w.SignData(MessageSignBytes(message))
+}
 
-	return ecdsa.Verify(publicKey, hash[:], r, s)
+// VerifyMessage verifies a signature made with SignMessage
+func VerifyMessage(publicKey Verifier, message string, signature string) bool {
+	return VerifySignature(publicKey, MessageSignBytes(message), signature)
+}
+
+// VerifySignature verifies a signed transaction or block; the signature's
+// key type tag must match the public key's scheme
+func VerifySignature(publicKey Verifier, data string, signature string) bool {
+	keyType, signatureBytes, err := DecodeSignature(signature)
+	if err != nil || keyType != publicKey.KeyType() {
+		return false
+	}
+	return publicKey.Verify([]byte(data), signatureBytes)
 }